@@ -0,0 +1,131 @@
+package zeptomail
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrAttachmentTooLarge is returned by the attachment and inline image
+// constructors when the content exceeds MaxAttachmentBytes.
+var ErrAttachmentTooLarge = errors.New("zeptomail: attachment too large")
+
+// AttachmentFromFile reads the file at path into a base64 Attachment named
+// after the file.
+func AttachmentFromFile(path string) (Attachment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer f.Close()
+	return AttachmentFromReader(filepath.Base(path), f)
+}
+
+// AttachmentFromFS reads name from fsys, e.g. an embed.FS, into a base64
+// Attachment.
+func AttachmentFromFS(fsys fs.FS, name string) (Attachment, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer f.Close()
+	return AttachmentFromReader(path.Base(name), f)
+}
+
+// AttachmentFromReader reads r into a base64 Attachment called name. The
+// MIME type comes from the name's extension, falling back to sniffing the
+// content.
+func AttachmentFromReader(name string, r io.Reader) (Attachment, error) {
+	content, err := readLimited(r)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("zeptomail: reading attachment %q: %w", name, err)
+	}
+	return newAttachment(name, content), nil
+}
+
+// InlineImageFromFile reads the image at path into a base64 InlineImage
+// referenced from the HTML body as "cid:<cid>".
+func InlineImageFromFile(cid, path string) (InlineImage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return InlineImage{}, err
+	}
+	defer f.Close()
+	return InlineImageFromReader(cid, filepath.Base(path), f)
+}
+
+// InlineImageFromFS reads the image name from fsys, e.g. an embed.FS holding
+// a logo, into a base64 InlineImage.
+func InlineImageFromFS(cid string, fsys fs.FS, name string) (InlineImage, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return InlineImage{}, err
+	}
+	defer f.Close()
+	return InlineImageFromReader(cid, path.Base(name), f)
+}
+
+// InlineImageFromReader reads an image from r into a base64 InlineImage. The
+// name is only used to detect the MIME type, which must be an image type.
+func InlineImageFromReader(cid, name string, r io.Reader) (InlineImage, error) {
+	content, err := readLimited(r)
+	if err != nil {
+		return InlineImage{}, fmt.Errorf("zeptomail: reading inline image %q: %w", name, err)
+	}
+	img := newInlineImage(cid, name, content)
+	if !isImageType(img.MimeType) {
+		return InlineImage{}, fmt.Errorf("zeptomail: inline image %q has non-image type %s", name, img.MimeType)
+	}
+	return img, nil
+}
+
+// readLimited reads all of r, failing once it exceeds MaxAttachmentBytes.
+func readLimited(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, MaxAttachmentBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > MaxAttachmentBytes {
+		return nil, ErrAttachmentTooLarge
+	}
+	return content, nil
+}
+
+func newAttachment(name string, content []byte) Attachment {
+	return Attachment{
+		Content:  base64.StdEncoding.EncodeToString(content),
+		MimeType: detectMimeType(name, content),
+		Name:     name,
+	}
+}
+
+// newInlineImage detects the MIME type like newAttachment. It does not
+// check that it is an image type; validation rejects those that are not.
+func newInlineImage(cid, name string, content []byte) InlineImage {
+	return InlineImage{
+		CID:      cid,
+		Content:  base64.StdEncoding.EncodeToString(content),
+		MimeType: detectMimeType(name, content),
+	}
+}
+
+func isImageType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "image/")
+}
+
+// detectMimeType prefers the type registered for the file extension and
+// falls back to sniffing the content.
+func detectMimeType(name string, content []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	return http.DetectContentType(content)
+}
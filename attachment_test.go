@@ -0,0 +1,118 @@
+package zeptomail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var pngBytes = []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A, 0, 0, 0, 0}
+
+func TestAttachmentFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := AttachmentFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "notes.txt" {
+		t.Errorf("Name = %q, want notes.txt", a.Name)
+	}
+	if !strings.HasPrefix(a.MimeType, "text/plain") {
+		t.Errorf("MimeType = %q, want text/plain", a.MimeType)
+	}
+	if a.Content != base64.StdEncoding.EncodeToString([]byte("hello")) {
+		t.Errorf("Content = %q", a.Content)
+	}
+}
+
+func TestAttachmentFromFile_Missing(t *testing.T) {
+	if _, err := AttachmentFromFile(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("err = %v, want not exist", err)
+	}
+}
+
+func TestAttachmentFromReader_SniffsUnknownExtension(t *testing.T) {
+	a, err := AttachmentFromReader("image.unknownext", bytes.NewReader(pngBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.MimeType != "image/png" {
+		t.Errorf("MimeType = %q, want image/png", a.MimeType)
+	}
+}
+
+func TestAttachmentFromReader_TooLarge(t *testing.T) {
+	r := bytes.NewReader(make([]byte, MaxAttachmentBytes+1))
+	if _, err := AttachmentFromReader("big.bin", r); !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Errorf("err = %v, want ErrAttachmentTooLarge", err)
+	}
+}
+
+func TestAttachmentFromFS(t *testing.T) {
+	fsys := fstest.MapFS{"docs/report.pdf": {Data: []byte("%PDF-1.4")}}
+	a, err := AttachmentFromFS(fsys, "docs/report.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "report.pdf" || a.MimeType != "application/pdf" {
+		t.Errorf("attachment = %+v", a)
+	}
+}
+
+func TestInlineImageFromFS(t *testing.T) {
+	fsys := fstest.MapFS{"assets/logo.png": {Data: pngBytes}}
+	img, err := InlineImageFromFS("logo", fsys, "assets/logo.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.CID != "logo" || img.MimeType != "image/png" {
+		t.Errorf("image = %+v", img)
+	}
+}
+
+func TestInlineImageFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(path, pngBytes, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	img, err := InlineImageFromFile("logo", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Content != base64.StdEncoding.EncodeToString(pngBytes) {
+		t.Errorf("Content = %q", img.Content)
+	}
+}
+
+func TestInlineImageFromReader_RejectsNonImage(t *testing.T) {
+	if _, err := InlineImageFromReader("doc", "doc.txt", strings.NewReader("text")); err == nil {
+		t.Error("expected error for non-image content")
+	}
+}
+
+func TestInlineImage_TypeDetectedAlike(t *testing.T) {
+	// An SVG is not recognised by sniffing alone, only by its extension.
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`)
+	img, err := InlineImageFromReader("logo", "logo.svg", bytes.NewReader(svg))
+	if err != nil || img.MimeType != "image/svg+xml" {
+		t.Errorf("InlineImageFromReader = %q, %v", img.MimeType, err)
+	}
+	if got := newInlineImage("logo", "logo.svg", svg).MimeType; got != img.MimeType {
+		t.Errorf("newInlineImage type = %q, want %q", got, img.MimeType)
+	}
+
+	_, err = NewEmail().From("a@example.com", "").To("b@example.com", "").Subject("s").
+		HTML(`<img src="cid:doc">`).Inline("doc", []byte("plain text")).Build()
+	if err == nil || !strings.Contains(err.Error(), "inline_images[0].mime_type") {
+		t.Errorf("Build with non-image inline content: err = %v", err)
+	}
+}
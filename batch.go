@@ -0,0 +1,172 @@
+package zeptomail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of chunks sent in parallel when
+// BatchOptions.Concurrency is not set.
+const DefaultBatchConcurrency = 4
+
+// BatchOptions controls how SendBatchEmailChunked and
+// SendBatchTemplateEmailChunked split and send a batch.
+type BatchOptions struct {
+	// ChunkSize is the number of To recipients per request. It defaults to,
+	// and is capped at, MaxBatchRecipients.
+	ChunkSize int
+	// Concurrency is the maximum number of requests in flight. It defaults to
+	// DefaultBatchConcurrency.
+	Concurrency int
+}
+
+// BatchChunk is the outcome of sending one chunk of a batch.
+type BatchChunk struct {
+	// Recipients are the To recipients of the chunk.
+	Recipients []Recipient
+	// Cc and Bcc are the copy recipients the chunk carried; only the first
+	// chunk has any.
+	Cc       []Recipient
+	Bcc      []Recipient
+	Response *SuccessResponse
+	Err      error
+}
+
+// RecipientResult is the outcome for a single recipient: the request ID of
+// the chunk that carried it, or the error that chunk failed with.
+type RecipientResult struct {
+	Chunk     int
+	RequestID string
+	Err       error
+}
+
+// BatchResult aggregates the outcome of a chunked batch send.
+type BatchResult struct {
+	// Chunks holds one entry per request, in recipient order.
+	Chunks []BatchChunk
+	// Recipients maps each To address to the outcome of its chunk.
+	// Addresses are unique, as duplicates in To are rejected.
+	Recipients map[string]RecipientResult
+}
+
+// Failed returns the To, Cc and Bcc recipients of the chunks that failed,
+// ready to be resent.
+func (r *BatchResult) Failed() (to, cc, bcc []Recipient) {
+	for _, c := range r.Chunks {
+		if c.Err != nil {
+			to = append(to, c.Recipients...)
+			cc = append(cc, c.Cc...)
+			bcc = append(bcc, c.Bcc...)
+		}
+	}
+	return to, cc, bcc
+}
+
+// Err joins the errors of all failed chunks, or returns nil if every chunk
+// was accepted.
+func (r *BatchResult) Err() error {
+	var errs []error
+	for i, c := range r.Chunks {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("chunk %d: %w", i, c.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendBatchEmailChunked splits req.To into chunks the API accepts and sends
+// them with SendBatchEmail, several at a time. Cc and Bcc recipients are only
+// included in the first chunk so that they receive a single copy.
+//
+// A To list that names an address twice is rejected with a
+// *ValidationError before anything is sent, since results are reported per
+// address. Otherwise the result is always returned; the error is non-nil
+// when at least one chunk failed and equals result.Err(). A ResponseMeta
+// requested with CaptureResponseMeta is left untouched.
+func (ec *EmailClient) SendBatchEmailChunked(ctx context.Context, req *EmailRequest, opts BatchOptions) (*BatchResult, error) {
+	return sendChunked(ctx, req.To, req.Cc, req.Bcc, opts, func(ctx context.Context, c *BatchChunk) (*SuccessResponse, error) {
+		chunk := *req
+		chunk.To, chunk.Cc, chunk.Bcc = c.Recipients, c.Cc, c.Bcc
+		return ec.SendBatchEmail(ctx, &chunk)
+	})
+}
+
+// SendBatchTemplateEmailChunked is the template counterpart of
+// SendBatchEmailChunked.
+func (ec *EmailClient) SendBatchTemplateEmailChunked(ctx context.Context, req *TemplateRequest, opts BatchOptions) (*BatchResult, error) {
+	return sendChunked(ctx, req.To, req.Cc, req.Bcc, opts, func(ctx context.Context, c *BatchChunk) (*SuccessResponse, error) {
+		chunk := *req
+		chunk.To, chunk.Cc, chunk.Bcc = c.Recipients, c.Cc, c.Bcc
+		return ec.SendBatchTemplateEmail(ctx, &chunk)
+	})
+}
+
+func sendChunked(ctx context.Context, to, cc, bcc []Recipient, opts BatchOptions, send func(ctx context.Context, c *BatchChunk) (*SuccessResponse, error)) (*BatchResult, error) {
+	var v validation
+	seen := make(map[string]int, len(to))
+	for i, r := range to {
+		if j, ok := seen[r.Address]; ok {
+			v.add(ValidationInvalid, fmt.Sprintf("to[%d].email_address.address", i), "%q is already listed as to[%d]", r.Address, j)
+			continue
+		}
+		seen[r.Address] = i
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	size := opts.ChunkSize
+	if size <= 0 || size > MaxBatchRecipients {
+		size = MaxBatchRecipients
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	result := &BatchResult{Recipients: make(map[string]RecipientResult, len(to))}
+	for start := 0; start < len(to) || start == 0; start += size {
+		end := start + size
+		if end > len(to) {
+			end = len(to)
+		}
+		result.Chunks = append(result.Chunks, BatchChunk{Recipients: to[start:end]})
+	}
+	result.Chunks[0].Cc, result.Chunks[0].Bcc = cc, bcc
+
+	// Chunks are sent concurrently and would race on a captured
+	// ResponseMeta; their responses are in result.Chunks instead.
+	ctx = withoutResponseMeta(ctx)
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range result.Chunks {
+		chunk := &result.Chunks[i]
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			chunk.Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			chunk.Response, chunk.Err = send(ctx, chunk)
+		}()
+	}
+	wg.Wait()
+
+	for i, c := range result.Chunks {
+		rr := RecipientResult{Chunk: i, Err: c.Err}
+		if c.Response != nil {
+			rr.RequestID = c.Response.RequestID
+		}
+		for _, r := range c.Recipients {
+			result.Recipients[r.Address] = rr
+		}
+	}
+	return result, result.Err()
+}
@@ -0,0 +1,183 @@
+package zeptomail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func makeRecipients(n int) []Recipient {
+	rs := make([]Recipient, n)
+	for i := range rs {
+		rs[i] = Recipient{EmailAddress: EmailAddress{Address: fmt.Sprintf("user%d@example.com", i)}}
+	}
+	return rs
+}
+
+func TestSendBatchEmailChunked_SplitsRecipients(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	var ccCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req EmailRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		sizes = append(sizes, len(req.To))
+		ccCount += len(req.Cc)
+		n := len(sizes)
+		mu.Unlock()
+		w.WriteHeader(200)
+		fmt.Fprintf(w, `{"data":[],"message":"OK","request_id":"req-%d"}`, n)
+	}))
+	defer ts.Close()
+
+	req := testEmailRequest()
+	req.To = makeRecipients(25)
+	req.Cc = []Recipient{{EmailAddress: EmailAddress{Address: "cc@example.com"}}}
+
+	client := newTestEmailClient(ts.URL)
+	res, err := client.SendBatchEmailChunked(context.Background(), req, BatchOptions{ChunkSize: 10, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Chunks) != 3 {
+		t.Fatalf("chunks = %d, want 3", len(res.Chunks))
+	}
+	total := 0
+	for _, s := range sizes {
+		total += s
+	}
+	if total != 25 || len(sizes) != 3 {
+		t.Errorf("sizes = %v, want 3 requests totalling 25", sizes)
+	}
+	if ccCount != 1 {
+		t.Errorf("cc sent %d times, want once", ccCount)
+	}
+	if len(res.Recipients) != 25 {
+		t.Errorf("Recipients has %d entries, want 25", len(res.Recipients))
+	}
+	if rr := res.Recipients["user24@example.com"]; rr.Chunk != 2 || rr.RequestID == "" {
+		t.Errorf("user24 result = %+v, want chunk 2 with a request ID", rr)
+	}
+	if len(req.To) != 25 || len(req.Cc) != 1 {
+		t.Error("original request must not be modified")
+	}
+}
+
+func TestSendBatchEmailChunked_PartialFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req EmailRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.To[0].Address == "user0@example.com" || req.To[0].Address == "user4@example.com" {
+			w.WriteHeader(500)
+			w.Write([]byte(`{"error":{"code":"GE_102","message":"boom"}}`))
+			return
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(successJSON()))
+	}))
+	defer ts.Close()
+
+	req := testEmailRequest()
+	req.To = makeRecipients(5)
+	req.Cc = []Recipient{{EmailAddress: EmailAddress{Address: "cc@example.com"}}}
+	req.Bcc = []Recipient{{EmailAddress: EmailAddress{Address: "bcc@example.com"}}}
+
+	client := newTestEmailClient(ts.URL)
+	res, err := client.SendBatchEmailChunked(context.Background(), req, BatchOptions{ChunkSize: 2})
+	if !errors.Is(err, ErrServerError) {
+		t.Fatalf("err = %v, want server error", err)
+	}
+	to, cc, bcc := res.Failed()
+	if len(to) != 3 || to[0].Address != "user0@example.com" || to[1].Address != "user1@example.com" || to[2].Address != "user4@example.com" {
+		t.Errorf("Failed() to = %v", to)
+	}
+	if len(cc) != 1 || cc[0].Address != "cc@example.com" || len(bcc) != 1 || bcc[0].Address != "bcc@example.com" {
+		t.Errorf("Failed() cc = %v, bcc = %v, want the copies of the first chunk", cc, bcc)
+	}
+	if rr := res.Recipients["user2@example.com"]; rr.Err != nil || rr.RequestID != "req-1" {
+		t.Errorf("user2 result = %+v, want success", rr)
+	}
+	if rr := res.Recipients["user4@example.com"]; rr.Err == nil {
+		t.Errorf("user4 result = %+v, want error", rr)
+	}
+}
+
+func TestSendBatchEmailChunked_RejectsDuplicates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent despite duplicate recipients")
+	}))
+	defer ts.Close()
+
+	req := testEmailRequest()
+	req.To = append(makeRecipients(3), makeRecipients(1)...)
+
+	res, err := newTestEmailClient(ts.URL).SendBatchEmailChunked(context.Background(), req, BatchOptions{ChunkSize: 2})
+	var verr *ValidationError
+	if res != nil || !errors.As(err, &verr) || len(verr.Details) != 1 || verr.Details[0].Target != "to[3].email_address.address" {
+		t.Errorf("got %v, %v; want a validation error for to[3]", res, err)
+	}
+}
+
+func TestSendBatchEmailChunked_BoundedConcurrency(t *testing.T) {
+	var inFlight, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		w.WriteHeader(200)
+		w.Write([]byte(successJSON()))
+	}))
+	defer ts.Close()
+
+	req := testEmailRequest()
+	req.To = makeRecipients(10)
+
+	client := newTestEmailClient(ts.URL)
+	if _, err := client.SendBatchEmailChunked(context.Background(), req, BatchOptions{ChunkSize: 1, Concurrency: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if peak > 3 {
+		t.Errorf("peak concurrency = %d, want at most 3", peak)
+	}
+}
+
+func TestSendBatchTemplateEmailChunked(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path != "/email/template/batch" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(successJSON()))
+	}))
+	defer ts.Close()
+
+	req := &TemplateRequest{
+		TemplateKey: "tpl",
+		From:        EmailAddress{Address: "a@b.com"},
+		To:          makeRecipients(MaxBatchRecipients + 1),
+	}
+	client := newTestEmailClient(ts.URL)
+	res, err := client.SendBatchTemplateEmailChunked(context.Background(), req, BatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || len(res.Chunks) != 2 {
+		t.Errorf("calls = %d, chunks = %d, want 2 with the default chunk size", calls, len(res.Chunks))
+	}
+}
@@ -0,0 +1,280 @@
+package zeptomail
+
+// message holds the fields EmailRequest and TemplateRequest share, as set
+// by a builder.
+type message struct {
+	From            EmailAddress
+	To, Cc, Bcc     []Recipient
+	ReplyTo         []EmailAddress
+	Subject         string
+	Attachments     []Attachment
+	InlineImages    []InlineImage
+	BounceAddress   string
+	TrackClicks     *bool
+	TrackOpens      *bool
+	ClientReference string
+	MimeHeaders     map[string]string
+	MergeInfo       map[string]string
+}
+
+// clone returns a copy of m that shares no slices or maps with it.
+func (m *message) clone() message {
+	c := *m
+	c.To, c.Cc, c.Bcc = cloneRecipients(m.To), cloneRecipients(m.Cc), cloneRecipients(m.Bcc)
+	c.ReplyTo = cloneSlice(m.ReplyTo)
+	c.Attachments = cloneSlice(m.Attachments)
+	c.InlineImages = cloneSlice(m.InlineImages)
+	c.MimeHeaders = cloneMap(m.MimeHeaders)
+	c.MergeInfo = cloneMap(m.MergeInfo)
+	return c
+}
+
+// messageBuilder implements the setters EmailBuilder and TemplateBuilder
+// share. B is the embedding builder, returned by every setter so that calls
+// can be chained.
+type messageBuilder[B any] struct {
+	self B
+	msg  message
+}
+
+func (b *messageBuilder[B]) From(address, name string) B {
+	b.msg.From = EmailAddress{Address: address, Name: name}
+	return b.self
+}
+
+func (b *messageBuilder[B]) To(address, name string) B {
+	b.msg.To = append(b.msg.To, newRecipient(address, name))
+	return b.self
+}
+
+// ToRecipients adds fully specified recipients, e.g. with per-recipient
+// MergeInfo for batch sends.
+func (b *messageBuilder[B]) ToRecipients(recipients ...Recipient) B {
+	b.msg.To = append(b.msg.To, recipients...)
+	return b.self
+}
+
+func (b *messageBuilder[B]) Cc(address, name string) B {
+	b.msg.Cc = append(b.msg.Cc, newRecipient(address, name))
+	return b.self
+}
+
+func (b *messageBuilder[B]) Bcc(address, name string) B {
+	b.msg.Bcc = append(b.msg.Bcc, newRecipient(address, name))
+	return b.self
+}
+
+func (b *messageBuilder[B]) ReplyTo(address, name string) B {
+	b.msg.ReplyTo = append(b.msg.ReplyTo, EmailAddress{Address: address, Name: name})
+	return b.self
+}
+
+// Attach adds an attachment, base64-encoding content. The MIME type is
+// derived from the file name, falling back to sniffing the content.
+func (b *messageBuilder[B]) Attach(name string, content []byte) B {
+	b.msg.Attachments = append(b.msg.Attachments, newAttachment(name, content))
+	return b.self
+}
+
+// AttachCached adds an attachment previously uploaded with FileCacheUpload.
+func (b *messageBuilder[B]) AttachCached(fileCacheKey string) B {
+	b.msg.Attachments = append(b.msg.Attachments, Attachment{FileCacheKey: fileCacheKey})
+	return b.self
+}
+
+// Inline adds an image referenced from the HTML body or template as
+// "cid:<cid>". The MIME type is sniffed from the content; Build fails if it
+// is not an image.
+func (b *messageBuilder[B]) Inline(cid string, content []byte) B {
+	b.msg.InlineImages = append(b.msg.InlineImages, newInlineImage(cid, "", content))
+	return b.self
+}
+
+func (b *messageBuilder[B]) Header(key, value string) B {
+	if b.msg.MimeHeaders == nil {
+		b.msg.MimeHeaders = make(map[string]string)
+	}
+	b.msg.MimeHeaders[key] = value
+	return b.self
+}
+
+func (b *messageBuilder[B]) TrackOpens(track bool) B {
+	b.msg.TrackOpens = &track
+	return b.self
+}
+
+func (b *messageBuilder[B]) TrackClicks(track bool) B {
+	b.msg.TrackClicks = &track
+	return b.self
+}
+
+func (b *messageBuilder[B]) BounceAddress(address string) B {
+	b.msg.BounceAddress = address
+	return b.self
+}
+
+func (b *messageBuilder[B]) ClientReference(ref string) B {
+	b.msg.ClientReference = ref
+	return b.self
+}
+
+// Merge sets a merge field shared by all recipients.
+func (b *messageBuilder[B]) Merge(key, value string) B {
+	if b.msg.MergeInfo == nil {
+		b.msg.MergeInfo = make(map[string]string)
+	}
+	b.msg.MergeInfo[key] = value
+	return b.self
+}
+
+// EmailBuilder assembles an EmailRequest. Create one with NewEmail, chain the
+// setters and finish with Build. The setters it shares with TemplateBuilder
+// are documented as returning B, which is *EmailBuilder here.
+type EmailBuilder struct {
+	messageBuilder[*EmailBuilder]
+	htmlBody string
+	textBody string
+}
+
+// NewEmail starts building an EmailRequest.
+func NewEmail() *EmailBuilder {
+	b := &EmailBuilder{}
+	b.self = b
+	return b
+}
+
+func (b *EmailBuilder) Subject(subject string) *EmailBuilder {
+	b.msg.Subject = subject
+	return b
+}
+
+func (b *EmailBuilder) HTML(body string) *EmailBuilder {
+	b.htmlBody = body
+	return b
+}
+
+func (b *EmailBuilder) Text(body string) *EmailBuilder {
+	b.textBody = body
+	return b
+}
+
+// Build validates and returns the request. Validation failures are
+// reported as a *ValidationError. The request shares no slices or maps with
+// the builder, which can be reused to build further requests.
+func (b *EmailBuilder) Build() (*EmailRequest, error) {
+	m := b.msg.clone()
+	req := &EmailRequest{
+		From:            m.From,
+		To:              m.To,
+		Cc:              m.Cc,
+		Bcc:             m.Bcc,
+		ReplyTo:         m.ReplyTo,
+		Subject:         m.Subject,
+		HTMLBody:        b.htmlBody,
+		TextBody:        b.textBody,
+		Attachments:     m.Attachments,
+		InlineImages:    m.InlineImages,
+		BounceAddress:   m.BounceAddress,
+		TrackClicks:     m.TrackClicks,
+		TrackOpens:      m.TrackOpens,
+		ClientReference: m.ClientReference,
+		MimeHeaders:     m.MimeHeaders,
+		MergeInfo:       m.MergeInfo,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// TemplateBuilder assembles a TemplateRequest. Create one with
+// NewTemplateEmail, chain the setters and finish with Build. The setters it
+// shares with EmailBuilder are documented as returning B, which is
+// *TemplateBuilder here.
+type TemplateBuilder struct {
+	messageBuilder[*TemplateBuilder]
+	templateKey   string
+	templateAlias string
+}
+
+// NewTemplateEmail starts building a TemplateRequest.
+func NewTemplateEmail() *TemplateBuilder {
+	b := &TemplateBuilder{}
+	b.self = b
+	return b
+}
+
+func (b *TemplateBuilder) TemplateKey(key string) *TemplateBuilder {
+	b.templateKey = key
+	return b
+}
+
+func (b *TemplateBuilder) TemplateAlias(alias string) *TemplateBuilder {
+	b.templateAlias = alias
+	return b
+}
+
+// Subject overrides the template's subject.
+func (b *TemplateBuilder) Subject(subject string) *TemplateBuilder {
+	b.msg.Subject = subject
+	return b
+}
+
+// Build validates and returns the request. Validation failures are
+// reported as a *ValidationError. The request shares no slices or maps with
+// the builder, which can be reused to build further requests.
+func (b *TemplateBuilder) Build() (*TemplateRequest, error) {
+	m := b.msg.clone()
+	req := &TemplateRequest{
+		TemplateKey:     b.templateKey,
+		TemplateAlias:   b.templateAlias,
+		BounceAddress:   m.BounceAddress,
+		From:            m.From,
+		To:              m.To,
+		Cc:              m.Cc,
+		Bcc:             m.Bcc,
+		ReplyTo:         m.ReplyTo,
+		Subject:         m.Subject,
+		Attachments:     m.Attachments,
+		InlineImages:    m.InlineImages,
+		TrackClicks:     m.TrackClicks,
+		TrackOpens:      m.TrackOpens,
+		ClientReference: m.ClientReference,
+		MimeHeaders:     m.MimeHeaders,
+		MergeInfo:       m.MergeInfo,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func newRecipient(address, name string) Recipient {
+	return Recipient{EmailAddress: EmailAddress{Address: address, Name: name}}
+}
+
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+func cloneMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneRecipients(rs []Recipient) []Recipient {
+	rs = cloneSlice(rs)
+	for i := range rs {
+		rs[i].MergeInfo = cloneMap(rs[i].MergeInfo)
+	}
+	return rs
+}
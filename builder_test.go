@@ -0,0 +1,143 @@
+package zeptomail
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestEmailBuilder_Build(t *testing.T) {
+	req, err := NewEmail().
+		From("sender@example.com", "Sender").
+		To("a@example.com", "A").
+		Cc("b@example.com", "").
+		Bcc("c@example.com", "").
+		ReplyTo("reply@example.com", "").
+		Subject("Hello").
+		HTML("<b>hi</b>").
+		Text("hi").
+		Attach("report.pdf", []byte("%PDF-1.4")).
+		Inline("logo", []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}).
+		Header("X-Campaign", "launch").
+		TrackOpens(true).
+		TrackClicks(false).
+		Merge("name", "A").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.From.Address != "sender@example.com" || req.From.Name != "Sender" {
+		t.Errorf("From = %+v", req.From)
+	}
+	if len(req.To) != 1 || len(req.Cc) != 1 || len(req.Bcc) != 1 || len(req.ReplyTo) != 1 {
+		t.Errorf("recipients = %d/%d/%d/%d, want 1 each", len(req.To), len(req.Cc), len(req.Bcc), len(req.ReplyTo))
+	}
+	if req.TrackOpens == nil || !*req.TrackOpens {
+		t.Error("TrackOpens should be true")
+	}
+	if req.TrackClicks == nil || *req.TrackClicks {
+		t.Error("TrackClicks should be false")
+	}
+	if req.MimeHeaders["X-Campaign"] != "launch" {
+		t.Errorf("MimeHeaders = %v", req.MimeHeaders)
+	}
+
+	att := req.Attachments[0]
+	if att.MimeType != "application/pdf" {
+		t.Errorf("attachment MimeType = %q, want application/pdf", att.MimeType)
+	}
+	if decoded, _ := base64.StdEncoding.DecodeString(att.Content); string(decoded) != "%PDF-1.4" {
+		t.Errorf("attachment content = %q", decoded)
+	}
+	if img := req.InlineImages[0]; img.CID != "logo" || img.MimeType != "image/png" {
+		t.Errorf("inline image = %+v", img)
+	}
+}
+
+func TestEmailBuilder_BuildInvalid(t *testing.T) {
+	_, err := NewEmail().From("not-an-address", "").Build()
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	for _, want := range []string{"from.address", "recipient", "subject", "htmlbody"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
+
+func TestEmailBuilder_BuildDoesNotAlias(t *testing.T) {
+	b := NewEmail().From("a@example.com", "").To("b@example.com", "").Subject("s").Text("t")
+	first, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Subject("changed")
+	if first.Subject != "s" {
+		t.Errorf("Subject = %q, earlier Build result should not change", first.Subject)
+	}
+}
+
+func TestEmailBuilder_ReuseAfterBuild(t *testing.T) {
+	b := NewEmail().From("a@example.com", "").
+		ToRecipients(Recipient{EmailAddress: EmailAddress{Address: "b@example.com"}, MergeInfo: map[string]string{"name": "B"}}).
+		Subject("s").Text("t").Header("X-A", "1").Merge("team", "ops")
+	first, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Header("X-B", "2").Merge("team", "dev").To("c@example.com", "")
+	b.msg.To[0].MergeInfo["name"] = "changed"
+	if _, err := b.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(first.MimeHeaders) != 1 || first.MimeHeaders["X-A"] != "1" {
+		t.Errorf("MimeHeaders = %v, want only X-A", first.MimeHeaders)
+	}
+	if first.MergeInfo["team"] != "ops" {
+		t.Errorf("MergeInfo = %v", first.MergeInfo)
+	}
+	if len(first.To) != 1 || first.To[0].MergeInfo["name"] != "B" {
+		t.Errorf("To = %+v", first.To)
+	}
+}
+
+func TestTemplateBuilder_ReuseAfterBuild(t *testing.T) {
+	b := NewTemplateEmail().TemplateKey("k").From("a@example.com", "").To("b@example.com", "").Header("X-A", "1")
+	first, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Header("X-B", "2").Merge("team", "dev")
+	if len(first.MimeHeaders) != 1 || first.MergeInfo != nil {
+		t.Errorf("MimeHeaders = %v, MergeInfo = %v", first.MimeHeaders, first.MergeInfo)
+	}
+}
+
+func TestTemplateBuilder_Build(t *testing.T) {
+	req, err := NewTemplateEmail().
+		TemplateAlias("welcome").
+		From("sender@example.com", "").
+		ToRecipients(Recipient{
+			EmailAddress: EmailAddress{Address: "a@example.com"},
+			MergeInfo:    map[string]string{"name": "A"},
+		}).
+		Merge("company", "Acme").
+		TrackOpens(true).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.TemplateAlias != "welcome" || req.To[0].MergeInfo["name"] != "A" || req.MergeInfo["company"] != "Acme" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+}
+
+func TestTemplateBuilder_RequiresTemplate(t *testing.T) {
+	_, err := NewTemplateEmail().From("a@example.com", "").To("b@example.com", "").Build()
+	if err == nil || !strings.Contains(err.Error(), "template_key") {
+		t.Errorf("err = %v, want template key error", err)
+	}
+}
@@ -0,0 +1,82 @@
+package zeptomail
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go/internal/transport"
+)
+
+// CallOption overrides a client setting for the calls made with a context
+// from WithCallOptions.
+type CallOption func(*transport.CallOptions)
+
+// WithCallOptions returns a context that applies opts to the EmailClient
+// and TemplatesClient calls made with it, on top of the options the client
+// was created with. Options already in ctx are kept unless opts override
+// them:
+//
+//	ctx = zeptomail.WithCallOptions(ctx, zeptomail.CallTimeout(5*time.Second), zeptomail.NoRetry())
+//	resp, err := client.SendEmail(ctx, req)
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	o := &transport.CallOptions{}
+	if prev := transport.CallOptionsFrom(ctx); prev != nil {
+		*o = *prev
+		o.Header = prev.Header.Clone()
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return transport.WithCallOptions(ctx, o)
+}
+
+// CallTimeout bounds the call, retries included. It cannot extend the
+// Timeout of the client's http.Client.
+func CallTimeout(d time.Duration) CallOption {
+	return func(o *transport.CallOptions) {
+		o.Timeout = d
+	}
+}
+
+// CallHeader sets a header on every request of the call. The Authorization
+// header cannot be overridden.
+func CallHeader(key, value string) CallOption {
+	return func(o *transport.CallOptions) {
+		if strings.EqualFold(key, "Authorization") {
+			return
+		}
+		if o.Header == nil {
+			o.Header = make(http.Header)
+		}
+		o.Header.Set(key, value)
+	}
+}
+
+// IdempotencyKey sends key as the Idempotency-Key header of every attempt,
+// so that retries of the same call can be recognised by proxies and by the
+// servers that honour it.
+func IdempotencyKey(key string) CallOption {
+	return CallHeader("Idempotency-Key", key)
+}
+
+// CallRetryPolicy replaces the client's retry policy for the call.
+func CallRetryPolicy(p RetryPolicy) CallOption {
+	return func(o *transport.CallOptions) {
+		o.Retry = &p
+	}
+}
+
+// NoRetry disables retries for the call.
+func NoRetry() CallOption {
+	return CallRetryPolicy(RetryPolicy{})
+}
+
+// CallBaseURL sends the call to url instead of the client's base URL, e.g.
+// to reach another data center.
+func CallBaseURL(url string) CallOption {
+	return func(o *transport.CallOptions) {
+		o.BaseURL = url
+	}
+}
@@ -0,0 +1,92 @@
+package zeptomail
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithCallOptions_Headers(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"request_id":"req-1","message":"OK"}`))
+	}))
+	defer ts.Close()
+
+	ctx := WithCallOptions(context.Background(), CallHeader("X-Tenant", "acme"))
+	ctx = WithCallOptions(ctx, IdempotencyKey("order-42"), CallHeader("Authorization", "stolen"))
+	if _, err := newTestEmailClient(ts.URL).SendEmail(ctx, testEmailRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("X-Tenant") != "acme" || got.Get("Idempotency-Key") != "order-42" {
+		t.Errorf("headers = %v", got)
+	}
+	if got.Get("Authorization") != "Zoho-enczapikey test-api-key" {
+		t.Errorf("Authorization = %q", got.Get("Authorization"))
+	}
+}
+
+func TestWithCallOptions_Retry(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewEmailClient("test-api-key", WithBaseURL(ts.URL),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond}))
+	if _, err := client.SendEmail(WithCallOptions(context.Background(), NoRetry()), testEmailRequest()); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("NoRetry: %d calls, want 1", calls)
+	}
+
+	calls = 0
+	policy := RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond}
+	if _, err := client.SendEmail(WithCallOptions(context.Background(), CallRetryPolicy(policy)), testEmailRequest()); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 2 {
+		t.Errorf("CallRetryPolicy: %d calls, want 2", calls)
+	}
+}
+
+func TestWithCallOptions_Timeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	ctx := WithCallOptions(context.Background(), CallTimeout(20*time.Millisecond))
+	_, err := newTestTemplatesClient(ts.URL).GetTemplate(ctx, "agent", "key")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestWithCallOptions_BaseURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"request_id":"req-1","message":"OK"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestEmailClient("http://127.0.0.1:1")
+	var meta ResponseMeta
+	ctx := CaptureResponseMeta(WithCallOptions(context.Background(), CallBaseURL(ts.URL)), &meta)
+	if _, err := client.SendEmail(ctx, testEmailRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if meta.StatusCode != http.StatusOK {
+		t.Errorf("meta = %+v", meta)
+	}
+}
@@ -17,6 +17,7 @@ const filesEndpoint = "/files"
 // EmailClient talks to the ZeptoMail transactional email endpoints.
 type EmailClient struct {
 	httpClient *transport.Client
+	validate   bool
 }
 
 // TemplatesClient talks to the ZeptoMail template CRUD endpoints.
@@ -30,29 +31,18 @@ func defaultHTTPClient() *http.Client {
 
 // NewEmailClient returns a client that authenticates with the given API key.
 func NewEmailClient(apiKey string, opts ...Option) *EmailClient {
-	cfg := &clientConfig{
-		httpClient: defaultHTTPClient(),
-		baseURL:    baseURL,
-	}
-	for _, opt := range opts {
-		opt(cfg)
-	}
+	cfg := newClientConfig(opts)
 	return &EmailClient{
-		httpClient: transport.NewEmailClient(apiKey, cfg.baseURL, cfg.httpClient),
+		httpClient: transport.NewEmailClient(apiKey, cfg.baseURL, cfg.httpClient, cfg.transportOptions()...),
+		validate:   !cfg.skipValidation,
 	}
 }
 
 // NewTemplatesClient returns a client that authenticates with the given OAuth token.
 func NewTemplatesClient(oAuthToken string, opts ...Option) *TemplatesClient {
-	cfg := &clientConfig{
-		httpClient: defaultHTTPClient(),
-		baseURL:    baseURL,
-	}
-	for _, opt := range opts {
-		opt(cfg)
-	}
+	cfg := newClientConfig(opts)
 	return &TemplatesClient{
-		httpClient: transport.NewTemplatesClient(oAuthToken, cfg.baseURL, cfg.httpClient),
+		httpClient: transport.NewTemplatesClient(oAuthToken, cfg.baseURL, cfg.httpClient, cfg.transportOptions()...),
 	}
 }
 
@@ -84,151 +74,124 @@ func checkForError(resp *transport.Response) error {
 	return apiErr
 }
 
-func (ec *EmailClient) handleResponse(resp *transport.Response) (*SuccessResponse, error) {
-	if err := checkForError(resp); err != nil {
+// invoke runs the call through the client's middleware chain, using send to
+// perform the HTTP exchange, and decodes a successful response into a new T.
+func invoke[T any](ctx context.Context, c *transport.Client, call *transport.Call, send func(context.Context, *transport.Call) (*transport.Response, error)) (*T, error) {
+	out, err := c.Invoke(ctx, call, func(ctx context.Context, call *transport.Call) (interface{}, error) {
+		resp, err := send(ctx, call)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkForError(resp); err != nil {
+			return nil, err
+		}
+
+		var v T
+		if err := json.Unmarshal(resp.Body, &v); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		return &v, nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	var successResp SuccessResponse
-	if err := json.Unmarshal(resp.Body, &successResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	v, ok := out.(*T)
+	if !ok {
+		return nil, fmt.Errorf("zeptomail: %s: unexpected result type %T", call.Operation, out)
 	}
-	return &successResp, nil
+	return v, nil
+}
+
+// send posts req to path. Unless validation is disabled, the payload is
+// validated first and a *ValidationError is returned without contacting the
+// API; this happens inside the middleware chain so middleware can still
+// adjust the payload.
+func (ec *EmailClient) send(ctx context.Context, operation, path string, req validator, batch bool) (*SuccessResponse, error) {
+	call := &transport.Call{Operation: operation, Method: "POST", Path: path, Payload: req}
+	return invoke[SuccessResponse](ctx, ec.httpClient, call, func(ctx context.Context, call *transport.Call) (*transport.Response, error) {
+		if v, ok := call.Payload.(validator); ok && ec.validate {
+			if err := v.validate(batch); err != nil {
+				return nil, err
+			}
+		}
+		return ec.httpClient.Do(ctx, call)
+	})
 }
 
 func (ec *EmailClient) SendEmail(ctx context.Context, req *EmailRequest) (*SuccessResponse, error) {
-	resp, err := ec.httpClient.Request(ctx, "POST", "/email", req)
-	if err != nil {
-		return nil, err
-	}
-	return ec.handleResponse(resp)
+	return ec.send(ctx, "SendEmail", "/email", req, false)
 }
 
 func (ec *EmailClient) SendBatchEmail(ctx context.Context, req *EmailRequest) (*SuccessResponse, error) {
-	resp, err := ec.httpClient.Request(ctx, "POST", "/email/batch", req)
-	if err != nil {
-		return nil, err
-	}
-	return ec.handleResponse(resp)
+	return ec.send(ctx, "SendBatchEmail", "/email/batch", req, true)
 }
 
 func (ec *EmailClient) SendTemplateEmail(ctx context.Context, req *TemplateRequest) (*SuccessResponse, error) {
-	resp, err := ec.httpClient.Request(ctx, "POST", "/email/template", req)
-	if err != nil {
-		return nil, err
-	}
-	return ec.handleResponse(resp)
+	resp, err := ec.send(ctx, "SendTemplateEmail", "/email/template", req, false)
+	return resp, withNotFound(err, ErrTemplateNotFound)
 }
 
 func (ec *EmailClient) SendBatchTemplateEmail(ctx context.Context, req *TemplateRequest) (*SuccessResponse, error) {
-	resp, err := ec.httpClient.Request(ctx, "POST", "/email/template/batch", req)
-	if err != nil {
-		return nil, err
-	}
-	return ec.handleResponse(resp)
+	resp, err := ec.send(ctx, "SendBatchTemplateEmail", "/email/template/batch", req, true)
+	return resp, withNotFound(err, ErrTemplateNotFound)
 }
 
 func (ec *EmailClient) FileCacheUpload(ctx context.Context, filename string, content []byte) (*FileUploadResponse, error) {
-	resp, err := ec.httpClient.Upload(ctx, filesEndpoint, filename, content)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := checkForError(resp); err != nil {
-		return nil, err
-	}
-
-	var fileResp FileUploadResponse
-	if err := json.Unmarshal(resp.Body, &fileResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	call := &transport.Call{
+		Operation: "FileCacheUpload",
+		Method:    "POST",
+		Path:      filesEndpoint,
+		Payload:   &FileUploadRequest{Filename: filename, Content: content},
 	}
-
-	return &fileResp, nil
+	return invoke[FileUploadResponse](ctx, ec.httpClient, call, func(ctx context.Context, call *transport.Call) (*transport.Response, error) {
+		upload := call.Payload.(*FileUploadRequest)
+		return ec.httpClient.Upload(ctx, call.Path, upload.Filename, upload.Content)
+	})
 }
 
 func (tc *TemplatesClient) CreateTemplate(ctx context.Context, mailagentAlias string, req *CreateTemplateRequest) (*CreateTemplateResponse, error) {
 	endpoint := fmt.Sprintf("/mailagents/%s/templates", url.PathEscape(mailagentAlias))
-	resp, err := tc.httpClient.Request(ctx, "POST", endpoint, req)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := checkForError(resp); err != nil {
-		return nil, err
-	}
-
-	var templateResp CreateTemplateResponse
-	if err := json.Unmarshal(resp.Body, &templateResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &templateResp, nil
+	call := &transport.Call{Operation: "CreateTemplate", Method: "POST", Path: endpoint, Payload: req}
+	return invoke[CreateTemplateResponse](ctx, tc.httpClient, call, tc.httpClient.Do)
 }
 
 func (tc *TemplatesClient) GetTemplate(ctx context.Context, mailagentAlias, templateKey string) (*GetTemplateResponse, error) {
 	endpoint := fmt.Sprintf("/mailagents/%s/templates/%s", url.PathEscape(mailagentAlias), url.PathEscape(templateKey))
-	resp, err := tc.httpClient.Request(ctx, "GET", endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := checkForError(resp); err != nil {
-		return nil, err
-	}
-
-	var templateResp GetTemplateResponse
-	if err := json.Unmarshal(resp.Body, &templateResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &templateResp, nil
+	call := &transport.Call{Operation: "GetTemplate", Method: "GET", Path: endpoint}
+	resp, err := invoke[GetTemplateResponse](ctx, tc.httpClient, call, tc.httpClient.Do)
+	return resp, withNotFound(err, ErrTemplateNotFound)
 }
 
 func (tc *TemplatesClient) UpdateTemplate(ctx context.Context, mailagentAlias, templateKey string, req *CreateTemplateRequest) (*CreateTemplateResponse, error) {
 	endpoint := fmt.Sprintf("/mailagents/%s/templates/%s", url.PathEscape(mailagentAlias), url.PathEscape(templateKey))
-	resp, err := tc.httpClient.Request(ctx, "PUT", endpoint, req)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := checkForError(resp); err != nil {
-		return nil, err
-	}
-
-	var templateResp CreateTemplateResponse
-	if err := json.Unmarshal(resp.Body, &templateResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &templateResp, nil
+	call := &transport.Call{Operation: "UpdateTemplate", Method: "PUT", Path: endpoint, Payload: req}
+	resp, err := invoke[CreateTemplateResponse](ctx, tc.httpClient, call, tc.httpClient.Do)
+	return resp, withNotFound(err, ErrTemplateNotFound)
 }
 
 func (tc *TemplatesClient) ListTemplates(ctx context.Context, mailagentAlias string, params ListTemplatesParams) (*ListTemplatesResponse, error) {
 	endpoint := fmt.Sprintf("/mailagents/%s/templates/?offset=%d&limit=%d",
 		url.PathEscape(mailagentAlias), params.Offset, params.Limit)
 
-	resp, err := tc.httpClient.Request(ctx, "GET", endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := checkForError(resp); err != nil {
-		return nil, err
-	}
-
-	var listResp ListTemplatesResponse
-	if err := json.Unmarshal(resp.Body, &listResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &listResp, nil
+	// The params travel in the query string; the payload is informational
+	// for middleware only.
+	call := &transport.Call{Operation: "ListTemplates", Method: "GET", Path: endpoint, Payload: params}
+	return invoke[ListTemplatesResponse](ctx, tc.httpClient, call, func(ctx context.Context, call *transport.Call) (*transport.Response, error) {
+		return tc.httpClient.Request(ctx, call.Method, call.Path, nil)
+	})
 }
 
 func (tc *TemplatesClient) DeleteTemplate(ctx context.Context, mailagentAlias, templateKey string) error {
 	endpoint := fmt.Sprintf("/mailagents/%s/templates/%s", url.PathEscape(mailagentAlias), url.PathEscape(templateKey))
-	resp, err := tc.httpClient.Request(ctx, "DELETE", endpoint, nil)
-	if err != nil {
-		return err
-	}
-	return checkForError(resp)
+	call := &transport.Call{Operation: "DeleteTemplate", Method: "DELETE", Path: endpoint}
+	_, err := tc.httpClient.Invoke(ctx, call, func(ctx context.Context, call *transport.Call) (interface{}, error) {
+		resp, err := tc.httpClient.Do(ctx, call)
+		if err != nil {
+			return nil, err
+		}
+		return nil, checkForError(resp)
+	})
+	return withNotFound(err, ErrTemplateNotFound)
 }
@@ -20,6 +20,16 @@ func errorJSON() string {
 	return `{"error":{"code":"INVALID_DATA","message":"bad request","details":[{"code":"REQUIRED","message":"to is required","target":"to"}],"request_id":"req-err"}}`
 }
 
+// testEmailRequest returns a request that passes local validation.
+func testEmailRequest() *EmailRequest {
+	return &EmailRequest{
+		From:     EmailAddress{Address: "a@b.com"},
+		To:       []Recipient{{EmailAddress: EmailAddress{Address: "c@d.com"}}},
+		Subject:  "hi",
+		TextBody: "hello",
+	}
+}
+
 func newTestEmailClient(url string) *EmailClient {
 	return NewEmailClient("test-api-key", WithBaseURL(url))
 }
@@ -38,11 +48,7 @@ func TestSendEmail_Success(t *testing.T) {
 	defer ts.Close()
 
 	client := newTestEmailClient(ts.URL)
-	resp, err := client.SendEmail(context.Background(), &EmailRequest{
-		From:    EmailAddress{Address: "a@b.com"},
-		To:      []Recipient{{EmailAddress: EmailAddress{Address: "c@d.com"}}},
-		Subject: "hi",
-	})
+	resp, err := client.SendEmail(context.Background(), testEmailRequest())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -95,10 +101,7 @@ func TestSendEmail_AuthHeader(t *testing.T) {
 	defer ts.Close()
 
 	client := newTestEmailClient(ts.URL)
-	_, err := client.SendEmail(context.Background(), &EmailRequest{
-		From: EmailAddress{Address: "a@b.com"},
-		To:   []Recipient{{EmailAddress: EmailAddress{Address: "c@d.com"}}},
-	})
+	_, err := client.SendEmail(context.Background(), testEmailRequest())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -116,7 +119,7 @@ func TestSendEmail_APIError(t *testing.T) {
 	defer ts.Close()
 
 	client := newTestEmailClient(ts.URL)
-	_, err := client.SendEmail(context.Background(), &EmailRequest{})
+	_, err := client.SendEmail(context.Background(), testEmailRequest())
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -147,7 +150,7 @@ func TestSendEmail_NonJSONError(t *testing.T) {
 	defer ts.Close()
 
 	client := newTestEmailClient(ts.URL)
-	_, err := client.SendEmail(context.Background(), &EmailRequest{})
+	_, err := client.SendEmail(context.Background(), testEmailRequest())
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -173,7 +176,7 @@ func TestSendEmail_NetworkError(t *testing.T) {
 	ts.Close()
 
 	client := newTestEmailClient(ts.URL)
-	_, err := client.SendEmail(context.Background(), &EmailRequest{})
+	_, err := client.SendEmail(context.Background(), testEmailRequest())
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -197,7 +200,7 @@ func TestSendEmail_ContextCancelled(t *testing.T) {
 	cancel() // Cancel immediately
 
 	client := newTestEmailClient(ts.URL)
-	_, err := client.SendEmail(ctx, &EmailRequest{})
+	_, err := client.SendEmail(ctx, testEmailRequest())
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -218,7 +221,7 @@ func TestSendEmail_ContextTimeout(t *testing.T) {
 	defer cancel()
 
 	client := newTestEmailClient(ts.URL)
-	_, err := client.SendEmail(ctx, &EmailRequest{})
+	_, err := client.SendEmail(ctx, testEmailRequest())
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -236,10 +239,7 @@ func TestSendBatchEmail_Success(t *testing.T) {
 	defer ts.Close()
 
 	client := newTestEmailClient(ts.URL)
-	_, err := client.SendBatchEmail(context.Background(), &EmailRequest{
-		From: EmailAddress{Address: "a@b.com"},
-		To:   []Recipient{{EmailAddress: EmailAddress{Address: "c@d.com"}}},
-	})
+	_, err := client.SendBatchEmail(context.Background(), testEmailRequest())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -559,11 +559,137 @@ func TestNewEmailClient_WithBaseURL(t *testing.T) {
 	defer ts.Close()
 
 	client := NewEmailClient("key", WithBaseURL(ts.URL))
-	_, err := client.SendEmail(context.Background(), &EmailRequest{
-		From: EmailAddress{Address: "a@b.com"},
-		To:   []Recipient{{EmailAddress: EmailAddress{Address: "c@d.com"}}},
-	})
+	_, err := client.SendEmail(context.Background(), testEmailRequest())
 	if err != nil {
 		t.Fatalf("expected request to custom base URL to succeed, got: %v", err)
 	}
 }
+
+func TestNewEmailClient_WithRetryPolicy(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(503)
+			return
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(successJSON()))
+	}))
+	defer ts.Close()
+
+	client := NewEmailClient("key", WithBaseURL(ts.URL), WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+	}))
+	resp, err := client.SendEmail(context.Background(), testEmailRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID != "req-1" || calls != 2 {
+		t.Errorf("RequestID = %q after %d calls, want req-1 after 2", resp.RequestID, calls)
+	}
+}
+
+func TestNewEmailClient_WithRateLimiterShared(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(successJSON()))
+	}))
+	defer ts.Close()
+
+	limiter := NewRateLimiter(0.1, 1)
+	a := NewEmailClient("key", WithBaseURL(ts.URL), WithRateLimiter(limiter))
+	b := NewEmailClient("key", WithBaseURL(ts.URL), WithRateLimiter(limiter))
+
+	if _, err := a.SendEmail(context.Background(), testEmailRequest()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := b.SendEmail(ctx, testEmailRequest()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want deadline exceeded while waiting for a token", err)
+	}
+}
+
+// --- Middleware ---
+
+func TestWithMiddleware_SeesTypedCall(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(successJSON()))
+	}))
+	defer ts.Close()
+
+	var gotOp string
+	var gotPayload interface{}
+	var gotResult interface{}
+	mw := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (interface{}, error) {
+			gotOp, gotPayload = call.Operation, call.Payload
+			out, err := next(ctx, call)
+			gotResult = out
+			return out, err
+		}
+	}
+
+	client := NewEmailClient("key", WithBaseURL(ts.URL), WithMiddleware(mw))
+	req := testEmailRequest()
+	resp, err := client.SendEmail(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotOp != "SendEmail" {
+		t.Errorf("Operation = %q, want SendEmail", gotOp)
+	}
+	if gotPayload != req {
+		t.Errorf("Payload = %v, want the *EmailRequest passed in", gotPayload)
+	}
+	if gotResult != resp {
+		t.Errorf("result = %v, want the *SuccessResponse returned", gotResult)
+	}
+}
+
+func TestWithMiddleware_SeesAPIError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		w.Write([]byte(`{"error":{"code":"NOT_FOUND","message":"Template not found"}}`))
+	}))
+	defer ts.Close()
+
+	var gotOp string
+	var gotErr error
+	mw := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (interface{}, error) {
+			out, err := next(ctx, call)
+			gotOp, gotErr = call.Operation, err
+			return out, err
+		}
+	}
+
+	client := NewTemplatesClient("token", WithBaseURL(ts.URL), WithMiddleware(mw))
+	if err := client.DeleteTemplate(context.Background(), "agent", "key"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	var apiErr *APIError
+	if gotOp != "DeleteTemplate" || !errors.As(gotErr, &apiErr) {
+		t.Errorf("middleware saw %q, %v; want DeleteTemplate and *APIError", gotOp, gotErr)
+	}
+}
+
+func TestWithMiddleware_ShortCircuit(t *testing.T) {
+	mw := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (interface{}, error) {
+			return &FileUploadResponse{FileCacheKey: "cached"}, nil
+		}
+	}
+
+	client := NewEmailClient("key", WithBaseURL("http://127.0.0.1:0"), WithMiddleware(mw))
+	resp, err := client.FileCacheUpload(context.Background(), "a.txt", []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.FileCacheKey != "cached" {
+		t.Errorf("FileCacheKey = %q, want cached", resp.FileCacheKey)
+	}
+}
@@ -0,0 +1,130 @@
+// Command zeptomail-relay accepts mail over SMTP and forwards it through the
+// ZeptoMail API, for programs that can only speak SMTP such as cron jobs and
+// monitoring tools.
+//
+// Usage:
+//
+//	ZEPTOMAIL_API_KEY=... zeptomail-relay [flags]
+//
+// The relay listens on 127.0.0.1:2525 by default. Each message is converted
+// into an EmailRequest and sent with EmailClient.SendEmail, retrying
+// transient failures. When the API stays unavailable, messages are spooled
+// to disk and resent in the background; without a spool directory the SMTP
+// client is asked to retry later instead.
+//
+// The flags are:
+//
+//	-listen addr       address to listen on (default 127.0.0.1:2525)
+//	-region code       ZeptoMail data center, e.g. eu or in (default us)
+//	-allow list        comma-separated senders allowed to relay, checked
+//	                   against both MAIL FROM and the From header; an entry
+//	                   starting with @ allows a whole domain (default: any)
+//	-auth-user name    require SMTP AUTH with this user name
+//	-auth-pass secret  password for -auth-user; also read from
+//	                   ZEPTOMAIL_RELAY_PASSWORD
+//	-max-size bytes    largest message accepted (default 15 MiB)
+//	-spool dir         directory for messages awaiting delivery
+//	-retry-interval d  how often spooled messages are retried (default 1m)
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:2525", "address to listen on")
+	region := flag.String("region", "us", "ZeptoMail data center")
+	allow := flag.String("allow", "", "comma-separated senders or @domains allowed to relay")
+	authUser := flag.String("auth-user", "", "require SMTP AUTH with this user name")
+	authPass := flag.String("auth-pass", os.Getenv("ZEPTOMAIL_RELAY_PASSWORD"), "password for -auth-user")
+	maxSize := flag.Int64("max-size", 15<<20, "largest message accepted, in bytes")
+	spoolDir := flag.String("spool", "", "directory for messages awaiting delivery")
+	retryInterval := flag.Duration("retry-interval", time.Minute, "how often spooled messages are retried")
+	flag.Parse()
+
+	logger := log.New(os.Stderr, "zeptomail-relay: ", log.LstdFlags)
+	if err := run(logger, options{
+		listen:        *listen,
+		region:        *region,
+		allow:         *allow,
+		authUser:      *authUser,
+		authPass:      *authPass,
+		maxSize:       *maxSize,
+		spoolDir:      *spoolDir,
+		retryInterval: *retryInterval,
+	}); err != nil {
+		logger.Fatal(err)
+	}
+}
+
+type options struct {
+	listen        string
+	region        string
+	allow         string
+	authUser      string
+	authPass      string
+	maxSize       int64
+	spoolDir      string
+	retryInterval time.Duration
+}
+
+func run(logger *log.Logger, opts options) error {
+	apiKey := os.Getenv("ZEPTOMAIL_API_KEY")
+	if apiKey == "" {
+		return fmt.Errorf("ZEPTOMAIL_API_KEY is not set")
+	}
+	region, err := zeptomail.ParseRegion(opts.region)
+	if err != nil {
+		return err
+	}
+	if opts.authUser != "" && opts.authPass == "" {
+		return fmt.Errorf("-auth-user requires -auth-pass or ZEPTOMAIL_RELAY_PASSWORD")
+	}
+
+	client := zeptomail.NewEmailClient(apiKey,
+		zeptomail.WithRegion(region),
+		zeptomail.WithRetryPolicy(zeptomail.DefaultRetryPolicy()),
+	)
+	r := &relay{client: client, allow: parseAllowlist(opts.allow), log: logger}
+	if opts.spoolDir != "" {
+		if r.spool, err = openSpool(opts.spoolDir, client, logger); err != nil {
+			return err
+		}
+	}
+
+	srv := &server{
+		relay:    r,
+		username: opts.authUser,
+		password: opts.authPass,
+		maxSize:  opts.maxSize,
+		log:      logger,
+	}
+	ln, err := net.Listen("tcp", opts.listen)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if r.spool != nil {
+		go r.spool.run(ctx, opts.retryInterval)
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	logger.Printf("listening on %s, relaying to %s", ln.Addr(), strings.TrimSuffix(region.BaseURL(), "/v1.1"))
+	return srv.Serve(ln)
+}
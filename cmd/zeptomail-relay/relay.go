@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go"
+)
+
+// sender is the part of zeptomail.EmailClient the relay uses.
+type sender interface {
+	SendEmail(ctx context.Context, req *zeptomail.EmailRequest) (*zeptomail.SuccessResponse, error)
+}
+
+// sendTimeout bounds one delivery attempt, retries included.
+const sendTimeout = 2 * time.Minute
+
+// relay forwards received messages to the API, spooling them when the API
+// is unavailable.
+type relay struct {
+	client sender
+	spool  *spool // nil without a spool directory
+	// allow restricts both the envelope sender and the From header.
+	allow allowlist
+	log   *log.Logger
+}
+
+// reply is an SMTP reply for the end of DATA.
+type reply struct {
+	code int
+	msg  string
+}
+
+// deliver converts a received message and sends it.
+func (r *relay) deliver(ctx context.Context, from string, rcpts []string, data []byte) reply {
+	req, err := buildRequest(from, rcpts, data)
+	if err != nil {
+		return reply{554, "5.6.0 " + err.Error()}
+	}
+	// The envelope sender was checked at MAIL, but the mail is sent from
+	// the From header, which must not name another sender.
+	if !r.allow.allows(req.From.Address) {
+		r.log.Printf("refused message from %s with From %s", from, req.From.Address)
+		return reply{553, "5.7.1 From address not allowed to relay"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	resp, err := r.client.SendEmail(ctx, req)
+	switch {
+	case err == nil:
+		r.log.Printf("sent message from %s to %d recipients, request %s", req.From.Address, len(rcpts), resp.RequestID)
+		return reply{250, "2.0.0 ok: " + resp.RequestID}
+	case permanent(err):
+		r.log.Printf("rejected message from %s: %v", req.From.Address, err)
+		return reply{554, "5.0.0 " + oneLine(err.Error())}
+	case r.spool != nil:
+		name, err2 := r.spool.put(req)
+		if err2 != nil {
+			r.log.Printf("spooling message from %s failed: %v (send error: %v)", req.From.Address, err2, err)
+			return reply{451, "4.3.0 temporary failure, try again later"}
+		}
+		r.log.Printf("spooled message from %s as %s: %v", req.From.Address, name, err)
+		return reply{250, "2.0.0 ok: queued as " + name}
+	default:
+		r.log.Printf("deferred message from %s: %v", req.From.Address, err)
+		return reply{451, "4.3.0 " + oneLine(err.Error())}
+	}
+}
+
+// permanent reports whether err will recur when the message is resent.
+func permanent(err error) bool {
+	return zeptomail.IsPermanent(err) || errors.Is(err, zeptomail.ErrInvalidRequest)
+}
+
+// buildRequest parses a message and reconciles its recipients with the
+// envelope: only envelope recipients receive the mail, those not named in
+// To or Cc are sent as Bcc. A message without a From header is sent from
+// the envelope sender.
+func buildRequest(from string, rcpts []string, data []byte) (*zeptomail.EmailRequest, error) {
+	req, err := zeptomail.ParseMIME(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if req.From.Address == "" {
+		req.From.Address = from
+	}
+
+	envelope := make(map[string]bool, len(rcpts))
+	for _, rcpt := range rcpts {
+		envelope[strings.ToLower(rcpt)] = true
+	}
+	keep := func(list []zeptomail.Recipient) []zeptomail.Recipient {
+		var out []zeptomail.Recipient
+		for _, r := range list {
+			key := strings.ToLower(r.Address)
+			if envelope[key] {
+				out = append(out, r)
+				delete(envelope, key)
+			}
+		}
+		return out
+	}
+	req.To = keep(req.To)
+	req.Cc = keep(req.Cc)
+	req.Bcc = nil
+	for _, rcpt := range rcpts {
+		if envelope[strings.ToLower(rcpt)] {
+			req.Bcc = append(req.Bcc, zeptomail.Recipient{EmailAddress: zeptomail.EmailAddress{Address: rcpt}})
+			delete(envelope, strings.ToLower(rcpt))
+		}
+	}
+	// ZeptoMail needs a To recipient; a message sent only to undisclosed
+	// recipients goes to them as To instead.
+	if len(req.To) == 0 {
+		req.To, req.Bcc = req.Bcc, nil
+	}
+	if len(req.To) == 0 {
+		return nil, fmt.Errorf("message has no recipients")
+	}
+	return req, nil
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/navnitms/zeptomail-sdk-go"
+	"github.com/navnitms/zeptomail-sdk-go/zeptomailtest"
+)
+
+type testRelay struct {
+	api   *zeptomailtest.Server
+	srv   *server
+	addr  string
+	spool *spool
+}
+
+// startRelay runs the relay against a fake API. Set spoolDir to enable
+// spooling.
+func startRelay(t *testing.T, spoolDir string, configure func(*server)) *testRelay {
+	t.Helper()
+	api := zeptomailtest.NewServer()
+	t.Cleanup(api.Close)
+
+	logger := log.New(io.Discard, "", 0)
+	r := &relay{client: api.EmailClient(), log: logger}
+	if spoolDir != "" {
+		var err error
+		if r.spool, err = openSpool(spoolDir, r.client, logger); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := &server{relay: r, maxSize: 1 << 20, log: logger}
+	if configure != nil {
+		configure(srv)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- srv.Serve(ln) }()
+	t.Cleanup(func() {
+		srv.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return &testRelay{api: api, srv: srv, addr: ln.Addr().String(), spool: r.spool}
+}
+
+const cronMessage = "From: Cron Daemon <cron@example.com>\r\n" +
+	"To: ops@example.org\r\n" +
+	"Subject: Cron <root@host> /usr/local/bin/backup\r\n" +
+	"X-Cron-Env: <SHELL=/bin/sh>\r\n" +
+	"\r\n" +
+	"backup finished\r\n" +
+	".hidden line starting with a dot\r\n"
+
+func replyCode(err error) int {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		return tpErr.Code
+	}
+	return 0
+}
+
+func TestRelay_Forwards(t *testing.T) {
+	tr := startRelay(t, "", nil)
+	err := smtp.SendMail(tr.addr, nil, "cron@example.com", []string{"ops@example.org", "audit@example.org"}, []byte(cronMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := tr.api.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	req := msgs[0].Email
+	if req.From.Address != "cron@example.com" || req.From.Name != "Cron Daemon" {
+		t.Errorf("From = %+v", req.From)
+	}
+	if len(req.To) != 1 || req.To[0].Address != "ops@example.org" {
+		t.Errorf("To = %+v", req.To)
+	}
+	if len(req.Bcc) != 1 || req.Bcc[0].Address != "audit@example.org" {
+		t.Errorf("envelope-only recipient not sent as Bcc: %+v", req.Bcc)
+	}
+	if req.Subject != "Cron <root@host> /usr/local/bin/backup" {
+		t.Errorf("Subject = %q", req.Subject)
+	}
+	if req.TextBody != "backup finished\n.hidden line starting with a dot\n" {
+		t.Errorf("TextBody = %q", req.TextBody)
+	}
+	if req.MimeHeaders["X-Cron-Env"] != "<SHELL=/bin/sh>" {
+		t.Errorf("MimeHeaders = %v", req.MimeHeaders)
+	}
+}
+
+func TestRelay_Auth(t *testing.T) {
+	tr := startRelay(t, "", func(s *server) {
+		s.username, s.password = "cron", "s3cret"
+	})
+	rcpts := []string{"ops@example.org"}
+
+	err := smtp.SendMail(tr.addr, nil, "cron@example.com", rcpts, []byte(cronMessage))
+	if replyCode(err) != 530 {
+		t.Errorf("without AUTH: %v, want 530", err)
+	}
+	err = smtp.SendMail(tr.addr, smtp.PlainAuth("", "cron", "wrong", "127.0.0.1"), "cron@example.com", rcpts, []byte(cronMessage))
+	if replyCode(err) != 535 {
+		t.Errorf("wrong password: %v, want 535", err)
+	}
+	if err := smtp.SendMail(tr.addr, smtp.PlainAuth("", "cron", "s3cret", "127.0.0.1"), "cron@example.com", rcpts, []byte(cronMessage)); err != nil {
+		t.Errorf("AUTH PLAIN: %v", err)
+	}
+	if err := smtp.SendMail(tr.addr, loginAuth{"cron", "s3cret"}, "cron@example.com", rcpts, []byte(cronMessage)); err != nil {
+		t.Errorf("AUTH LOGIN: %v", err)
+	}
+	if n := len(tr.api.Messages()); n != 2 {
+		t.Errorf("got %d messages, want 2", n)
+	}
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp lacks.
+type loginAuth struct{ user, pass string }
+
+func (a loginAuth) Start(*smtp.ServerInfo) (string, []byte, error) { return "LOGIN", nil, nil }
+
+func (a loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	if strings.HasPrefix(string(fromServer), "User") {
+		return []byte(a.user), nil
+	}
+	return []byte(a.pass), nil
+}
+
+func TestRelay_Allowlist(t *testing.T) {
+	tr := startRelay(t, "", func(s *server) {
+		s.relay.allow = parseAllowlist("alerts@example.net, @example.com")
+	})
+	rcpts := []string{"ops@example.org"}
+	for _, from := range []string{"cron@example.com", "ALERTS@example.net"} {
+		if err := smtp.SendMail(tr.addr, nil, from, rcpts, []byte(cronMessage)); err != nil {
+			t.Errorf("%s: %v", from, err)
+		}
+	}
+	for _, from := range []string{"spam@example.org", "cron@sub.example.com"} {
+		if err := smtp.SendMail(tr.addr, nil, from, rcpts, []byte(cronMessage)); replyCode(err) != 553 {
+			t.Errorf("%s: %v, want 553", from, err)
+		}
+	}
+}
+
+func TestRelay_AllowlistForgedFrom(t *testing.T) {
+	tr := startRelay(t, "", func(s *server) {
+		s.relay.allow = parseAllowlist("@example.com")
+	})
+	forged := strings.Replace(cronMessage, "cron@example.com", "ceo@victim.com", 1)
+	err := smtp.SendMail(tr.addr, nil, "cron@example.com", []string{"ops@example.org"}, []byte(forged))
+	if replyCode(err) != 553 {
+		t.Errorf("forged From: %v, want 553", err)
+	}
+	if n := len(tr.api.Messages()); n != 0 {
+		t.Errorf("%d messages sent with a forged From", n)
+	}
+}
+
+func TestRelay_Rejections(t *testing.T) {
+	tr := startRelay(t, "", func(s *server) { s.maxSize = 64 })
+	rcpts := []string{"ops@example.org"}
+
+	if err := smtp.SendMail(tr.addr, nil, "cron@example.com", rcpts, []byte(cronMessage)); replyCode(err) != 552 {
+		t.Errorf("oversized: %v, want 552", err)
+	}
+
+	tr.srv.maxSize = 1 << 20
+	tr.api.FailNext(400, "TM_3201")
+	if err := smtp.SendMail(tr.addr, nil, "cron@example.com", rcpts, []byte(cronMessage)); replyCode(err) != 554 {
+		t.Errorf("permanent API error: %v, want 554", err)
+	}
+
+	// Without a spool the client is told to try again later.
+	tr.api.Fail(zeptomailtest.Failure{Status: 503, Times: 10})
+	if err := smtp.SendMail(tr.addr, nil, "cron@example.com", rcpts, []byte(cronMessage)); replyCode(err) != 451 {
+		t.Errorf("API unavailable: %v, want 451", err)
+	}
+}
+
+func TestRelay_Spool(t *testing.T) {
+	dir := t.TempDir()
+	tr := startRelay(t, dir, nil)
+	tr.api.Fail(zeptomailtest.Failure{Status: 503, Times: 10})
+
+	if err := smtp.SendMail(tr.addr, nil, "cron@example.com", []string{"ops@example.org"}, []byte(cronMessage)); err != nil {
+		t.Fatalf("message not accepted for spooling: %v", err)
+	}
+	pending, err := tr.spool.pending()
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("pending = %v, %v", pending, err)
+	}
+	if n := len(tr.api.Messages()); n != 0 {
+		t.Fatalf("%d messages sent while the API was down", n)
+	}
+
+	// Still down: the message stays spooled.
+	ctx := context.Background()
+	if sent, err := tr.spool.flush(ctx); sent != 0 || err == nil {
+		t.Errorf("flush while down = %d, %v", sent, err)
+	}
+
+	tr.api.Reset()
+	if sent, err := tr.spool.flush(ctx); sent != 1 || err != nil {
+		t.Fatalf("flush = %d, %v", sent, err)
+	}
+	if msgs := tr.api.Messages(); len(msgs) != 1 || msgs[0].Email.Subject != "Cron <root@host> /usr/local/bin/backup" {
+		t.Errorf("messages after flush = %+v", msgs)
+	}
+	if pending, _ := tr.spool.pending(); len(pending) != 0 {
+		t.Errorf("pending after flush = %v", pending)
+	}
+}
+
+func TestSpool_MovesRejectedAside(t *testing.T) {
+	dir := t.TempDir()
+	api := zeptomailtest.NewServer()
+	defer api.Close()
+	sp, err := openSpool(dir, api.EmailClient(zeptomail.WithValidation(false)), log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sp.put(&zeptomail.EmailRequest{Subject: "incomplete"}); err != nil {
+		t.Fatal(err)
+	}
+	api.FailNext(400, "TM_3201")
+	if sent, err := sp.flush(context.Background()); sent != 0 || err != nil {
+		t.Errorf("flush = %d, %v", sent, err)
+	}
+	failed, _ := os.ReadDir(dir + "/failed")
+	if pending, _ := sp.pending(); len(pending) != 0 || len(failed) != 1 {
+		t.Errorf("pending %v, failed %d", pending, len(failed))
+	}
+}
+
+func TestBuildRequest_UndisclosedRecipients(t *testing.T) {
+	msg := "From: app@example.com\r\nTo: undisclosed-recipients:;\r\nSubject: hi\r\n\r\nbody\r\n"
+	req, err := buildRequest("app@example.com", []string{"a@example.org", "b@example.org"}, []byte(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.To) != 2 || len(req.Bcc) != 0 {
+		t.Errorf("To = %+v, Bcc = %+v", req.To, req.Bcc)
+	}
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commandTimeout bounds the wait for the next SMTP command or data line.
+const commandTimeout = 5 * time.Minute
+
+// allowlist restricts the senders that may relay. Entries are addresses or
+// domains written as "@example.com". An empty list allows every sender.
+type allowlist []string
+
+func parseAllowlist(s string) allowlist {
+	var a allowlist
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			a = append(a, entry)
+		}
+	}
+	return a
+}
+
+func (a allowlist) allows(address string) bool {
+	if len(a) == 0 {
+		return true
+	}
+	address = strings.ToLower(address)
+	at := strings.LastIndexByte(address, '@')
+	for _, entry := range a {
+		if entry == address || (at >= 0 && strings.HasPrefix(entry, "@") && entry == address[at:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// server is a minimal SMTP submission server: EHLO, AUTH PLAIN and LOGIN,
+// MAIL, RCPT, DATA, RSET, NOOP and QUIT.
+type server struct {
+	relay *relay
+	// username and password, when set, must be presented with AUTH before
+	// mail is accepted.
+	username string
+	password string
+	maxSize  int64
+	log      *log.Logger
+
+	mu     sync.Mutex
+	ln     net.Listener
+	conns  map[net.Conn]bool
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Serve accepts connections on ln until Close is called.
+func (s *server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.ln = ln
+	s.conns = make(map[net.Conn]bool)
+	s.mu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				s.wg.Wait()
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			s.session(conn)
+		}()
+	}
+}
+
+// Close stops accepting connections and ends open sessions. A session that
+// is relaying a message still sends its reply; one waiting for input is
+// closed, leaving the client to retry.
+func (s *server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.SetReadDeadline(time.Now())
+	}
+	if s.ln == nil {
+		return nil
+	}
+	return s.ln.Close()
+}
+
+// session holds the state of one SMTP connection.
+type session struct {
+	s      *server
+	conn   net.Conn
+	tp     *textproto.Conn
+	authed bool
+	from   string
+	rcpts  []string
+	inMail bool
+}
+
+func (s *server) session(conn net.Conn) {
+	ss := &session{s: s, conn: conn, tp: textproto.NewConn(conn)}
+	ss.reply(220, "zeptomail-relay ESMTP ready")
+	for {
+		conn.SetReadDeadline(time.Now().Add(commandTimeout))
+		line, err := ss.tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			ss.reset()
+			lines := []string{"zeptomail-relay", "8BITMIME", fmt.Sprintf("SIZE %d", s.maxSize)}
+			if s.username != "" {
+				lines = append(lines, "AUTH PLAIN LOGIN")
+			}
+			ss.multiline(250, lines)
+		case "HELO":
+			ss.reset()
+			ss.reply(250, "zeptomail-relay")
+		case "AUTH":
+			ss.auth(arg)
+		case "MAIL":
+			ss.mail(arg)
+		case "RCPT":
+			ss.rcpt(arg)
+		case "DATA":
+			if !ss.data() {
+				return
+			}
+		case "RSET":
+			ss.reset()
+			ss.reply(250, "2.0.0 ok")
+		case "NOOP":
+			ss.reply(250, "2.0.0 ok")
+		case "VRFY":
+			ss.reply(252, "2.5.2 cannot verify, but will try")
+		case "QUIT":
+			ss.reply(221, "2.0.0 bye")
+			return
+		default:
+			ss.reply(502, "5.5.2 command not recognized")
+		}
+	}
+}
+
+func (ss *session) reply(code int, msg string) {
+	ss.tp.PrintfLine("%d %s", code, msg)
+}
+
+func (ss *session) multiline(code int, lines []string) {
+	for i, l := range lines {
+		sep := "-"
+		if i == len(lines)-1 {
+			sep = " "
+		}
+		ss.tp.PrintfLine("%d%s%s", code, sep, l)
+	}
+}
+
+func (ss *session) reset() {
+	ss.from, ss.rcpts, ss.inMail = "", nil, false
+}
+
+func (ss *session) auth(arg string) {
+	if ss.s.username == "" {
+		ss.reply(503, "5.5.1 authentication not enabled")
+		return
+	}
+	if ss.authed {
+		ss.reply(503, "5.5.1 already authenticated")
+		return
+	}
+	mech, initial, _ := strings.Cut(arg, " ")
+	var user, pass string
+	switch strings.ToUpper(mech) {
+	case "PLAIN":
+		if initial == "" {
+			var ok bool
+			if initial, ok = ss.challenge(""); !ok {
+				return
+			}
+		}
+		b, err := base64.StdEncoding.DecodeString(initial)
+		parts := strings.Split(string(b), "\x00")
+		if err != nil || len(parts) != 3 {
+			ss.reply(501, "5.5.2 malformed AUTH PLAIN response")
+			return
+		}
+		user, pass = parts[1], parts[2]
+	case "LOGIN":
+		var ok bool
+		if initial == "" {
+			if initial, ok = ss.challenge("Username:"); !ok {
+				return
+			}
+		}
+		u, err := base64.StdEncoding.DecodeString(initial)
+		if err != nil {
+			ss.reply(501, "5.5.2 malformed AUTH LOGIN response")
+			return
+		}
+		resp, ok := ss.challenge("Password:")
+		if !ok {
+			return
+		}
+		p, err := base64.StdEncoding.DecodeString(resp)
+		if err != nil {
+			ss.reply(501, "5.5.2 malformed AUTH LOGIN response")
+			return
+		}
+		user, pass = string(u), string(p)
+	default:
+		ss.reply(504, "5.5.4 unrecognized authentication type")
+		return
+	}
+
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(ss.s.username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(ss.s.password)) == 1
+	if !userOK || !passOK {
+		ss.s.log.Printf("authentication failed for %q from %s", user, ss.conn.RemoteAddr())
+		ss.reply(535, "5.7.8 authentication credentials invalid")
+		return
+	}
+	ss.authed = true
+	ss.reply(235, "2.7.0 authentication successful")
+}
+
+// challenge sends a 334 prompt and reads the client's base64 response. A
+// response of "*" cancels the exchange.
+func (ss *session) challenge(prompt string) (string, bool) {
+	ss.reply(334, base64.StdEncoding.EncodeToString([]byte(prompt)))
+	line, err := ss.tp.ReadLine()
+	if err != nil {
+		return "", false
+	}
+	if line == "*" {
+		ss.reply(501, "5.0.0 authentication cancelled")
+		return "", false
+	}
+	return line, true
+}
+
+func (ss *session) mail(arg string) {
+	switch {
+	case ss.s.username != "" && !ss.authed:
+		ss.reply(530, "5.7.0 authentication required")
+		return
+	case ss.inMail:
+		ss.reply(503, "5.5.1 sender already given")
+		return
+	}
+	from, ok := pathArg(arg, "FROM:")
+	if !ok {
+		ss.reply(501, "5.5.4 syntax: MAIL FROM:<address>")
+		return
+	}
+	if !ss.s.relay.allow.allows(from) {
+		ss.reply(553, "5.7.1 sender not allowed to relay")
+		return
+	}
+	ss.from, ss.inMail = from, true
+	ss.reply(250, "2.1.0 ok")
+}
+
+func (ss *session) rcpt(arg string) {
+	if !ss.inMail {
+		ss.reply(503, "5.5.1 need MAIL first")
+		return
+	}
+	to, ok := pathArg(arg, "TO:")
+	if !ok || to == "" {
+		ss.reply(501, "5.5.4 syntax: RCPT TO:<address>")
+		return
+	}
+	ss.rcpts = append(ss.rcpts, to)
+	ss.reply(250, "2.1.5 ok")
+}
+
+// data receives and relays a message. It returns false if the connection
+// broke.
+func (ss *session) data() bool {
+	if len(ss.rcpts) == 0 {
+		ss.reply(503, "5.5.1 need RCPT first")
+		return true
+	}
+	ss.reply(354, "end data with <CR><LF>.<CR><LF>")
+	ss.conn.SetReadDeadline(time.Now().Add(commandTimeout))
+	dr := ss.tp.DotReader()
+	msg, err := io.ReadAll(io.LimitReader(dr, ss.s.maxSize+1))
+	if err == nil && int64(len(msg)) > ss.s.maxSize {
+		_, err = io.Copy(io.Discard, dr)
+		if err == nil {
+			ss.reply(552, "5.3.4 message too large")
+			ss.reset()
+			return true
+		}
+	}
+	if err != nil {
+		return false
+	}
+
+	r := ss.s.relay.deliver(context.Background(), ss.from, ss.rcpts, msg)
+	ss.reply(r.code, r.msg)
+	ss.reset()
+	return true
+}
+
+// pathArg extracts the address from "FROM:<addr> PARAMS" or "TO:<addr>".
+func pathArg(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path, _, _ := strings.Cut(strings.TrimSpace(arg[len(prefix):]), " ")
+	if !strings.HasPrefix(path, "<") || !strings.HasSuffix(path, ">") {
+		return "", false
+	}
+	return path[1 : len(path)-1], true
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go"
+)
+
+// spool keeps requests that could not be sent on disk, one JSON file each,
+// and resends them in the background. Requests the API rejects for good are
+// moved to the failed subdirectory for inspection.
+type spool struct {
+	dir    string
+	client sender
+	log    *log.Logger
+}
+
+func openSpool(dir string, client sender, logger *log.Logger) (*spool, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "failed"), 0o700); err != nil {
+		return nil, fmt.Errorf("creating spool directory: %w", err)
+	}
+	return &spool{dir: dir, client: client, log: logger}, nil
+}
+
+// put stores req and returns its file name. The file is written under a
+// temporary name first so that flush never sees a partial request.
+func (s *spool) put(req *zeptomail.EmailRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	var b [4]byte
+	rand.Read(b[:])
+	name := fmt.Sprintf("%d-%s.json", time.Now().UnixNano(), hex.EncodeToString(b[:]))
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return name, nil
+}
+
+// pending lists the spooled files, oldest first.
+func (s *spool) pending() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// flush tries to send every spooled request. It stops at the first
+// transient failure, as the API is most likely still unavailable, and
+// returns the number of requests sent.
+func (s *spool) flush(ctx context.Context) (int, error) {
+	names, err := s.pending()
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, name := range names {
+		path := filepath.Join(s.dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return sent, err
+		}
+		var req zeptomail.EmailRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			s.log.Printf("spool: %s is corrupt, moving it aside: %v", name, err)
+			os.Rename(path, filepath.Join(s.dir, "failed", name))
+			continue
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		resp, err := s.client.SendEmail(sendCtx, &req)
+		cancel()
+		switch {
+		case err == nil:
+			s.log.Printf("spool: sent %s, request %s", name, resp.RequestID)
+			os.Remove(path)
+			sent++
+		case permanent(err):
+			s.log.Printf("spool: %s rejected, moving it aside: %v", name, err)
+			os.Rename(path, filepath.Join(s.dir, "failed", name))
+		default:
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// run flushes the spool every interval until ctx is done.
+func (s *spool) run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if _, err := s.flush(ctx); err != nil && ctx.Err() == nil {
+			s.log.Printf("spool: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
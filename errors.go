@@ -1,6 +1,12 @@
 package zeptomail
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
 
 // APIError is returned when the ZeptoMail API responds with a non-2xx status.
 type APIError struct {
@@ -9,6 +15,10 @@ type APIError struct {
 	Message        string
 	Details        []ErrorDetail
 	RequestID      string
+
+	// notFound narrows a 404 to the resource the request addressed, such
+	// as ErrTemplateNotFound.
+	notFound error
 }
 
 func (e *APIError) Error() string {
@@ -17,3 +27,108 @@ func (e *APIError) Error() string {
 	}
 	return fmt.Sprintf("zeptomail: HTTP %d: %s - %s", e.HTTPStatusCode, e.Code, e.Message)
 }
+
+// Sentinel errors classifying an *APIError. Match them with errors.Is:
+//
+//	if errors.Is(err, zeptomail.ErrRateLimited) { ... }
+var (
+	ErrInvalidRequest      = errors.New("zeptomail: invalid request")
+	ErrUnauthorized        = errors.New("zeptomail: unauthorized")
+	ErrForbidden           = errors.New("zeptomail: forbidden")
+	ErrNotFound            = errors.New("zeptomail: not found")
+	ErrTemplateNotFound    = errors.New("zeptomail: template not found")
+	ErrInsufficientCredits = errors.New("zeptomail: insufficient credits")
+	ErrRateLimited         = errors.New("zeptomail: rate limited")
+	ErrServerError         = errors.New("zeptomail: server error")
+)
+
+// errorCodes maps error codes whose meaning is documented to their
+// category. Everything else, including the sub-codes in Details, is
+// classified by HTTP status, which the API sets for every error.
+//
+// TM_ codes: https://www.zoho.com/zeptomail/help/api/error-codes.html
+// OAuth codes: https://www.zoho.com/accounts/protocol/oauth/use-access-token.html
+var errorCodes = map[string]error{
+	"TM_3201":            ErrInvalidRequest, // mandatory field missing
+	"TM_3301":            ErrInvalidRequest, // invalid request syntax or value
+	"TM_4001":            ErrUnauthorized,   // access denied
+	"INVALID_OAUTHTOKEN": ErrUnauthorized,
+	"INVALID_OAUTHSCOPE": ErrForbidden,
+}
+
+// errorParents links narrow categories to the broader ones they imply.
+var errorParents = map[error]error{
+	ErrTemplateNotFound: ErrNotFound,
+}
+
+// category returns the sentinel describing e, or nil if it is unclassified.
+func (e *APIError) category() error {
+	if err, ok := errorCodes[e.Code]; ok {
+		return err
+	}
+	if e.HTTPStatusCode == http.StatusNotFound && e.notFound != nil {
+		return e.notFound
+	}
+	switch {
+	case e.HTTPStatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.HTTPStatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.HTTPStatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.HTTPStatusCode == http.StatusPaymentRequired:
+		return ErrInsufficientCredits
+	case e.HTTPStatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.HTTPStatusCode >= 500:
+		return ErrServerError
+	case e.HTTPStatusCode >= 400:
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+// withNotFound records on a 404 *APIError in err that the resource the
+// request addressed is the one missing, e.g. the template of a template
+// endpoint.
+func withNotFound(err, sentinel error) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusNotFound {
+		apiErr.notFound = sentinel
+	}
+	return err
+}
+
+// Is lets errors.Is match an APIError against the sentinel errors above.
+func (e *APIError) Is(target error) bool {
+	for c := e.category(); c != nil; c = errorParents[c] {
+		if c == target {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRetryable reports whether err is a transient failure that may succeed
+// when retried: rate limiting, server errors and network errors.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServerError) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsPermanent reports whether err is an API error that will fail again if
+// the same request is resent, such as invalid input or bad credentials.
+func IsPermanent(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && !IsRetryable(err)
+}
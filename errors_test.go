@@ -1,7 +1,10 @@
 package zeptomail
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
 	"testing"
 )
 
@@ -69,3 +72,74 @@ func TestAPIError_ErrorsAs(t *testing.T) {
 		t.Errorf("Details[0].Target = %q, want %q", apiErr.Details[0].Target, "to")
 	}
 }
+
+func TestAPIError_IsSentinel(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *APIError
+		want error
+	}{
+		{"code table", &APIError{HTTPStatusCode: 400, Code: "TM_3201"}, ErrInvalidRequest},
+		{"template endpoint 404", &APIError{HTTPStatusCode: 404, notFound: ErrTemplateNotFound}, ErrTemplateNotFound},
+		{"unmapped code", &APIError{HTTPStatusCode: 500, Code: "SERR_157"}, ErrServerError},
+		{"oauth code", &APIError{HTTPStatusCode: 401, Code: "INVALID_OAUTHTOKEN"}, ErrUnauthorized},
+		{"401 status", &APIError{HTTPStatusCode: 401, Code: "Unauthorized"}, ErrUnauthorized},
+		{"403 status", &APIError{HTTPStatusCode: 403}, ErrForbidden},
+		{"404 status", &APIError{HTTPStatusCode: 404}, ErrNotFound},
+		{"422 status", &APIError{HTTPStatusCode: 422, Code: "SOMETHING_NEW"}, ErrInvalidRequest},
+		{"429 status", &APIError{HTTPStatusCode: 429}, ErrRateLimited},
+		{"502 status", &APIError{HTTPStatusCode: 502, Code: "Bad Gateway"}, ErrServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", tt.err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIError_IsParentCategory(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &APIError{HTTPStatusCode: 404, notFound: ErrTemplateNotFound})
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("template not found should also match ErrNotFound")
+	}
+	if errors.Is(err, ErrInvalidRequest) {
+		t.Error("template not found should not match ErrInvalidRequest")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"rate limited", &APIError{HTTPStatusCode: 429}, true},
+		{"server error", &APIError{HTTPStatusCode: 503}, true},
+		{"invalid request", &APIError{HTTPStatusCode: 400, Code: "TM_3301"}, false},
+		{"network", fmt.Errorf("error sending request: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), true},
+		{"context", fmt.Errorf("error sending request: %w", context.Canceled), false},
+		{"plain", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	if !IsPermanent(&APIError{HTTPStatusCode: 401}) {
+		t.Error("401 should be permanent")
+	}
+	if IsPermanent(&APIError{HTTPStatusCode: 429}) {
+		t.Error("429 should not be permanent")
+	}
+	if IsPermanent(errors.New("boom")) {
+		t.Error("non-API errors should not be permanent")
+	}
+}
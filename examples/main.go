@@ -113,6 +113,7 @@ func sendBatchEmail(ctx context.Context, client *zeptomail.EmailClient) {
 				},
 			},
 		},
+		Subject:  "Your invoice",
 		HTMLBody: "<div><b>This is a sample email.{{contact}} {{company}}</b></div>",
 		TextBody: "This is a sample email",
 	}
@@ -0,0 +1,28 @@
+package zeptomail
+
+import "context"
+
+// Sender sends email. EmailClient implements it; depend on Sender instead of
+// *EmailClient to substitute a mock or another backend.
+type Sender interface {
+	SendEmail(ctx context.Context, req *EmailRequest) (*SuccessResponse, error)
+	SendBatchEmail(ctx context.Context, req *EmailRequest) (*SuccessResponse, error)
+	SendTemplateEmail(ctx context.Context, req *TemplateRequest) (*SuccessResponse, error)
+	SendBatchTemplateEmail(ctx context.Context, req *TemplateRequest) (*SuccessResponse, error)
+	FileCacheUpload(ctx context.Context, filename string, content []byte) (*FileUploadResponse, error)
+}
+
+// TemplateManager manages the templates of a mail agent. TemplatesClient
+// implements it.
+type TemplateManager interface {
+	CreateTemplate(ctx context.Context, mailagentAlias string, req *CreateTemplateRequest) (*CreateTemplateResponse, error)
+	GetTemplate(ctx context.Context, mailagentAlias, templateKey string) (*GetTemplateResponse, error)
+	UpdateTemplate(ctx context.Context, mailagentAlias, templateKey string, req *UpdateTemplateRequest) (*CreateTemplateResponse, error)
+	ListTemplates(ctx context.Context, mailagentAlias string, params ListTemplatesParams) (*ListTemplatesResponse, error)
+	DeleteTemplate(ctx context.Context, mailagentAlias, templateKey string) error
+}
+
+var (
+	_ Sender          = (*EmailClient)(nil)
+	_ TemplateManager = (*TemplatesClient)(nil)
+)
@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// CallOptions override the client's settings for a single call. Zero
+// fields leave the client's settings in place.
+type CallOptions struct {
+	// Timeout bounds the whole call, retries included.
+	Timeout time.Duration
+	// Header is added to every request of the call.
+	Header http.Header
+	// Retry replaces the client's retry policy.
+	Retry *RetryPolicy
+	// BaseURL replaces the client's base URL.
+	BaseURL string
+}
+
+type callOptionsKey struct{}
+
+// WithCallOptions returns a context carrying o.
+func WithCallOptions(ctx context.Context, o *CallOptions) context.Context {
+	return context.WithValue(ctx, callOptionsKey{}, o)
+}
+
+// CallOptionsFrom returns the CallOptions in ctx, or nil if there are none.
+func CallOptionsFrom(ctx context.Context) *CallOptions {
+	o, _ := ctx.Value(callOptionsKey{}).(*CallOptions)
+	return o
+}
+
+// retryPolicy returns the retry policy for the call ctx belongs to.
+func (c *Client) retryPolicy(ctx context.Context) *RetryPolicy {
+	if o := CallOptionsFrom(ctx); o != nil && o.Retry != nil {
+		return o.Retry
+	}
+	return c.retry
+}
+
+// url returns the URL of path for the call ctx belongs to.
+func (c *Client) url(ctx context.Context, path string) string {
+	if o := CallOptionsFrom(ctx); o != nil && o.BaseURL != "" {
+		return o.BaseURL + path
+	}
+	return c.baseURL + path
+}
@@ -0,0 +1,42 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInvoke_CallOptions(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("X-Call") != "1" {
+			t.Error("call header missing")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c := NewEmailClient("key", "http://127.0.0.1:1", ts.Client(), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond}))
+	ctx := WithCallOptions(context.Background(), &CallOptions{
+		Timeout: time.Minute,
+		Header:  http.Header{"X-Call": {"1"}},
+		Retry:   &RetryPolicy{},
+		BaseURL: ts.URL,
+	})
+	_, err := c.Invoke(ctx, &Call{Method: "GET", Path: "/"}, func(ctx context.Context, call *Call) (interface{}, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("no deadline set")
+		}
+		return c.Do(ctx, call)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("%d calls, want 1", calls)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 type AuthType int
@@ -19,6 +20,7 @@ const (
 
 type Response struct {
 	StatusCode int
+	Header     http.Header
 	Body       []byte
 }
 
@@ -28,77 +30,229 @@ type Client struct {
 	apiKey     string
 	oAuthToken string
 	authType   AuthType
+	retry      *RetryPolicy
+	limiter    *RateLimiter
+	middleware []Middleware
+	tokens     *tokenCache
 }
 
-func NewEmailClient(apiKey string, baseURL string, httpClient *http.Client) *Client {
-	return &Client{
+// Option configures optional Client behaviour.
+type Option func(*Client)
+
+// WithRetryPolicy makes the client retry failed attempts according to p.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = &p
+	}
+}
+
+// WithRateLimiter paces every attempt, retries included, through l.
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(c *Client) {
+		c.limiter = l
+	}
+}
+
+func NewEmailClient(apiKey string, baseURL string, httpClient *http.Client, opts ...Option) *Client {
+	c := &Client{
 		httpClient: httpClient,
 		baseURL:    baseURL,
 		apiKey:     apiKey,
 		authType:   AuthTypeAPI,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-func NewTemplatesClient(oAuthToken string, baseURL string, httpClient *http.Client) *Client {
-	return &Client{
+func NewTemplatesClient(oAuthToken string, baseURL string, httpClient *http.Client, opts ...Option) *Client {
+	c := &Client{
 		httpClient: httpClient,
 		baseURL:    baseURL,
 		oAuthToken: oAuthToken,
 		authType:   AuthTypeTemplates,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-func (c *Client) setAuthHeader(req *http.Request) {
+// setAuthHeader authenticates req and returns the OAuth token it used, if
+// any. With a token source the token is fetched, or taken from the cache.
+func (c *Client) setAuthHeader(ctx context.Context, req *http.Request) (string, error) {
 	switch c.authType {
 	case AuthTypeAPI:
 		req.Header.Set("Authorization", "Zoho-enczapikey "+c.apiKey)
 	case AuthTypeTemplates:
-		req.Header.Set("Authorization", "Zoho-oauthtoken "+c.oAuthToken)
+		token := c.oAuthToken
+		if c.tokens != nil {
+			var err error
+			if token, err = c.tokens.token(ctx); err != nil {
+				return "", fmt.Errorf("error fetching OAuth token: %w", err)
+			}
+		}
+		req.Header.Set("Authorization", "Zoho-oauthtoken "+token)
+		return token, nil
 	}
+	return "", nil
 }
 
 func (c *Client) Upload(ctx context.Context, path, filename string, content []byte) (*Response, error) {
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path+"?name="+url.QueryEscape(filename), bytes.NewReader(content))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", http.DetectContentType(content))
-	c.setAuthHeader(req)
+	contentType := http.DetectContentType(content)
+	return c.do(ctx, true, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.url(ctx, path)+"?name="+url.QueryEscape(filename), bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", contentType)
+		return req, nil
+	})
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
+// UploadReader streams body to path. size is sent as the Content-Length when
+// positive. The upload is only retried when body implements io.Seeker, in
+// which case it is rewound to its starting offset before every attempt.
+func (c *Client) UploadReader(ctx context.Context, path, filename, contentType string, body io.Reader, size int64) (*Response, error) {
+	seeker, rewindable := body.(io.Seeker)
+	var start int64
+	if rewindable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			rewindable = false
+		}
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
-	}
+	first := true
+	return c.do(ctx, rewindable, func() (*http.Request, error) {
+		if !first {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("rewinding body: %w", err)
+			}
+		}
+		first = false
 
-	return &Response{StatusCode: resp.StatusCode, Body: respBody}, nil
+		req, err := http.NewRequestWithContext(ctx, "POST", c.url(ctx, path)+"?name="+url.QueryEscape(filename), io.NopCloser(body))
+		if err != nil {
+			return nil, err
+		}
+		if size > 0 {
+			req.ContentLength = size
+		}
+		req.Header.Set("Content-Type", contentType)
+		return req, nil
+	})
 }
 
 func (c *Client) Request(ctx context.Context, method, path string, payload interface{}) (*Response, error) {
-	var body io.Reader
+	var jsonData []byte
 	if payload != nil {
-		jsonData, err := json.Marshal(payload)
+		var err error
+		jsonData, err = json.Marshal(payload)
 		if err != nil {
 			return nil, fmt.Errorf("error marshaling request: %w", err)
 		}
-		body = bytes.NewBuffer(jsonData)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+	return c.do(ctx, true, func() (*http.Request, error) {
+		var body io.Reader
+		if jsonData != nil {
+			body = bytes.NewReader(jsonData)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.url(ctx, path), body)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+}
+
+// do sends the request built by newRequest, retrying according to the
+// call's retry policy, by default the client's. newRequest is called once
+// per attempt so that every attempt gets a fresh body; bodies that cannot be
+// rewound get one attempt.
+func (c *Client) do(ctx context.Context, rewindable bool, newRequest func() (*http.Request, error)) (*Response, error) {
+	retry := c.retryPolicy(ctx)
+	attempts := 1
+	if rewindable {
+		attempts = retry.attempts()
+	}
+	var lastResp *Response
+	var lastErr error
+	refreshed := false
+	ex := ExchangeFrom(ctx)
+	if ex != nil {
+		start := time.Now()
+		defer func() { ex.Duration += time.Since(start) }()
 	}
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			if attempt > 1 {
+				return lastResp, lastErr
+			}
+			return nil, fmt.Errorf("error waiting for rate limiter: %w", err)
+		}
 
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-	c.setAuthHeader(req)
+		req, err := newRequest()
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+		token, err := c.setAuthHeader(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if ex != nil {
+			ex.prepare(req)
+		}
+
+		resp, err := c.send(req)
+		if ex != nil {
+			ex.record(req, resp)
+		}
+
+		// A rejected OAuth token may have been revoked or expired early:
+		// refresh it once and resend without counting a retry.
+		if err == nil && c.tokens != nil && rewindable && !refreshed && tokenRejected(resp) {
+			refreshed = true
+			c.tokens.invalidate(token)
+			attempt--
+			continue
+		}
+
+		if attempt >= attempts {
+			return resp, err
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			wait = retry.backoff(attempt)
+		case retry.retryableStatus(resp.StatusCode):
+			wait = retry.backoff(attempt)
+			if d, ok := retryAfter(resp.Header, time.Now()); ok {
+				if retry.MaxBackoff > 0 && d > retry.MaxBackoff {
+					return resp, nil
+				}
+				wait = d
+			}
+		default:
+			return resp, nil
+		}
+
+		if sleep(ctx, wait) != nil {
+			return resp, err
+		}
+		lastResp, lastErr = resp, err
+	}
+}
 
+func (c *Client) send(req *http.Request) (*Response, error) {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error sending request: %w", err)
@@ -110,5 +264,5 @@ func (c *Client) Request(ctx context.Context, method, path string, payload inter
 		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
 
-	return &Response{StatusCode: resp.StatusCode, Body: respBody}, nil
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody}, nil
 }
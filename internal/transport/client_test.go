@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -174,3 +175,23 @@ func TestUpload_TextContent(t *testing.T) {
 		t.Errorf("Content-Type = %q, want %q", gotContentType, "text/plain; charset=utf-8")
 	}
 }
+
+func TestUploadReader_SetsLengthAndType(t *testing.T) {
+	var gotLength int64
+	var gotType, gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody, gotLength, gotType = string(b), r.ContentLength, r.Header.Get("Content-Type")
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	c := NewEmailClient("key", ts.URL, ts.Client())
+	_, err := c.UploadReader(context.Background(), "/files", "a.bin", "application/octet-stream", strings.NewReader("12345"), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotBody != "12345" || gotLength != 5 || gotType != "application/octet-stream" {
+		t.Errorf("got body %q, length %d, type %q", gotBody, gotLength, gotType)
+	}
+}
@@ -0,0 +1,72 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Exchange records the HTTP side of one call. Invoke attaches a fresh
+// Exchange to the context of every call, so that middleware can read what
+// happened on the wire once next returns, and add headers before it is
+// called.
+type Exchange struct {
+	// Attempts is the number of HTTP requests sent, retries and token
+	// refreshes included.
+	Attempts int
+	// StatusCode, Header and Body describe the last response. StatusCode
+	// is zero if no response was received.
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// RequestHeader holds the headers of the last request as sent. It
+	// includes the Authorization header, which must be redacted before the
+	// headers are logged.
+	RequestHeader http.Header
+	// Duration is the time spent in HTTP requests, backoff included.
+	Duration time.Duration
+
+	// extra headers are added to every request of the call.
+	extra http.Header
+}
+
+type exchangeKey struct{}
+
+// WithExchange returns a context carrying ex.
+func WithExchange(ctx context.Context, ex *Exchange) context.Context {
+	return context.WithValue(ctx, exchangeKey{}, ex)
+}
+
+// ExchangeFrom returns the Exchange of the call ctx belongs to, or nil
+// outside a call.
+func ExchangeFrom(ctx context.Context) *Exchange {
+	ex, _ := ctx.Value(exchangeKey{}).(*Exchange)
+	return ex
+}
+
+// SetHeader sets a header on every request of the call, e.g. a trace
+// context header.
+func (ex *Exchange) SetHeader(key, value string) {
+	if ex.extra == nil {
+		ex.extra = make(http.Header)
+	}
+	ex.extra.Set(key, value)
+}
+
+// prepare adds the extra headers to req.
+func (ex *Exchange) prepare(req *http.Request) {
+	for k, v := range ex.extra {
+		req.Header[k] = append([]string(nil), v...)
+	}
+}
+
+// record notes an attempt and its response, if any.
+func (ex *Exchange) record(req *http.Request, resp *Response) {
+	ex.Attempts++
+	ex.RequestHeader = req.Header.Clone()
+	if resp != nil {
+		ex.StatusCode = resp.StatusCode
+		ex.Header = resp.Header
+		ex.Body = resp.Body
+	}
+}
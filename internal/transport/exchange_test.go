@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInvoke_Exchange(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Traceparent") != "00-trace" {
+			t.Errorf("extra header missing on attempt %d", calls+1)
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Request-Id", "abc")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	c := NewEmailClient("key", ts.URL, ts.Client(), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond}))
+	var ex *Exchange
+	_, err := c.Invoke(context.Background(), &Call{Method: "GET", Path: "/"}, func(ctx context.Context, call *Call) (interface{}, error) {
+		ex = ExchangeFrom(ctx)
+		ex.SetHeader("Traceparent", "00-trace")
+		return c.Do(ctx, call)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex.Attempts != 2 || ex.StatusCode != http.StatusOK || ex.Header.Get("X-Request-Id") != "abc" || string(ex.Body) != "{}" || ex.Duration <= 0 {
+		t.Errorf("exchange = %+v", ex)
+	}
+	if ex.RequestHeader.Get("Authorization") != "Zoho-enczapikey key" {
+		t.Errorf("RequestHeader = %v", ex.RequestHeader)
+	}
+
+	if ExchangeFrom(context.Background()) != nil {
+		t.Error("ExchangeFrom outside a call is not nil")
+	}
+}
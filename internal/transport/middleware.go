@@ -0,0 +1,55 @@
+package transport
+
+import "context"
+
+// Call describes one logical SDK operation as seen by middleware.
+type Call struct {
+	// Operation is the SDK method name, e.g. "SendEmail" or "CreateTemplate".
+	Operation string
+	Method    string
+	Path      string
+	// Payload is the typed request value, e.g. *zeptomail.EmailRequest.
+	// It is nil for operations without a request body.
+	Payload interface{}
+}
+
+// Handler performs a call and returns its typed result, or an error which
+// for API failures is a *zeptomail.APIError.
+type Handler func(ctx context.Context, call *Call) (interface{}, error)
+
+// Middleware wraps a Handler to run code around every call.
+type Middleware func(next Handler) Handler
+
+// WithMiddleware appends mw to the client's middleware chain. The first
+// middleware registered is the outermost one.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+// Invoke runs h wrapped in the client's middleware chain, with a fresh
+// Exchange in the context. The timeout and headers of any CallOptions in
+// ctx are applied here, around the whole chain.
+func (c *Client) Invoke(ctx context.Context, call *Call, h Handler) (interface{}, error) {
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	ex := &Exchange{}
+	if o := CallOptionsFrom(ctx); o != nil {
+		if o.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+			defer cancel()
+		}
+		if len(o.Header) > 0 {
+			ex.extra = o.Header.Clone()
+		}
+	}
+	return h(WithExchange(ctx, ex), call)
+}
+
+// Do sends call.Payload as JSON to call.Path using call.Method.
+func (c *Client) Do(ctx context.Context, call *Call) (*Response, error) {
+	return c.Request(ctx, call.Method, call.Path, call.Payload)
+}
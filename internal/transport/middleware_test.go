@@ -0,0 +1,57 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestInvoke_MiddlewareOrder(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) (interface{}, error) {
+				order = append(order, name+":before")
+				out, err := next(ctx, call)
+				order = append(order, name+":after")
+				return out, err
+			}
+		}
+	}
+
+	c := NewEmailClient("key", "http://unused", http.DefaultClient, WithMiddleware(mw("a"), mw("b")))
+	out, err := c.Invoke(context.Background(), &Call{Operation: "Op"}, func(ctx context.Context, call *Call) (interface{}, error) {
+		order = append(order, "handler:"+call.Operation)
+		return "result", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "result" {
+		t.Errorf("out = %v, want result", out)
+	}
+
+	want := []string{"a:before", "b:before", "handler:Op", "b:after", "a:after"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestDo_SendsCallPayload(t *testing.T) {
+	var gotMethod, gotPath string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	c := NewTemplatesClient("token", ts.URL, ts.Client())
+	if _, err := c.Do(context.Background(), &Call{Method: "PUT", Path: "/x"}); err != nil {
+		t.Fatal(err)
+	}
+	if gotMethod != "PUT" || gotPath != "/x" {
+		t.Errorf("got %s %s, want PUT /x", gotMethod, gotPath)
+	}
+}
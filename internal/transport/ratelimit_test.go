@@ -0,0 +1,88 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_AllowsBurst(t *testing.T) {
+	l := NewRateLimiter(1, 3)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("burst took %v, want no waiting", d)
+	}
+}
+
+func TestRateLimiter_PacesAfterBurst(t *testing.T) {
+	l := NewRateLimiter(20, 1)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Errorf("3 requests at 20/s with burst 1 took %v, want about 100ms", d)
+	}
+}
+
+func TestRateLimiter_ContextCancelled(t *testing.T) {
+	l := NewRateLimiter(0.1, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want deadline exceeded", err)
+	}
+
+	// The failed wait must hand its reservation back.
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens < -0.01 {
+		t.Errorf("tokens = %v, want the reservation returned", tokens)
+	}
+}
+
+func TestRateLimiter_NilAndUnlimited(t *testing.T) {
+	var l *RateLimiter
+	if err := l.Wait(context.Background()); err != nil {
+		t.Errorf("nil limiter: %v", err)
+	}
+	if err := NewRateLimiter(0, 1).Wait(context.Background()); err != nil {
+		t.Errorf("zero rate: %v", err)
+	}
+}
+
+func TestRateLimiter_SharedBetweenClients(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	l := NewRateLimiter(0.1, 1)
+	email := NewEmailClient("key", ts.URL, ts.Client(), WithRateLimiter(l))
+	templates := NewTemplatesClient("token", ts.URL, ts.Client(), WithRateLimiter(l))
+
+	if _, err := email.Request(context.Background(), "POST", "/email", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := templates.Request(ctx, "GET", "/mailagents/a/templates", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the shared bucket to be exhausted", err)
+	}
+}
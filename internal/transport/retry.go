@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. The zero value
+// disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry. It doubles on every
+	// further attempt.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the computed backoff. When the server asks for a
+	// longer wait with Retry-After, the retries stop and that response is
+	// returned. Zero means no cap.
+	MaxBackoff time.Duration
+	// Jitter randomly shortens each backoff by up to this fraction (0 to 1).
+	Jitter float64
+	// RetryableStatuses lists the HTTP status codes that trigger a retry.
+	// When nil, DefaultRetryableStatuses is used.
+	RetryableStatuses []int
+}
+
+// DefaultRetryableStatuses are retried when RetryPolicy.RetryableStatuses is nil.
+var DefaultRetryableStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 2 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) retryableStatus(code int) bool {
+	statuses := p.RetryableStatuses
+	if statuses == nil {
+		statuses = DefaultRetryableStatuses
+	}
+	for _, s := range statuses {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the given retry (1 for the first retry).
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseBackoff
+	for i := 1; i < retry; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		j := p.Jitter
+		if j > 1 {
+			j = 1
+		}
+		d -= time.Duration(float64(d) * j * rand.Float64())
+	}
+	return d
+}
+
+// retryAfter parses a Retry-After header given either as delay seconds or as
+// an HTTP date.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done. It gives up straight away when the
+// context deadline would expire before d has elapsed.
+func sleep(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return context.DeadlineExceeded
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,251 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fastRetry(attempts int) Option {
+	return WithRetryPolicy(RetryPolicy{MaxAttempts: attempts, BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+}
+
+func TestRetry_RetriesServerErrors(t *testing.T) {
+	var calls int32
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	c := NewEmailClient("key", ts.URL, ts.Client(), fastRetry(3))
+	resp, err := c.Request(context.Background(), "POST", "/email", map[string]string{"subject": "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+	for i, b := range bodies {
+		if b != `{"subject":"hi"}` {
+			t.Errorf("attempt %d body = %q, want full payload", i+1, b)
+		}
+	}
+}
+
+func TestRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(429)
+	}))
+	defer ts.Close()
+
+	c := NewEmailClient("key", ts.URL, ts.Client(), fastRetry(2))
+	resp, err := c.Request(context.Background(), "POST", "/email", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 429 {
+		t.Errorf("StatusCode = %d, want 429", resp.StatusCode)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestRetry_SkipsNonRetryableStatus(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(400)
+	}))
+	defer ts.Close()
+
+	c := NewEmailClient("key", ts.URL, ts.Client(), fastRetry(3))
+	if _, err := c.Request(context.Background(), "POST", "/email", nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRetry_CustomStatuses(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(409)
+	}))
+	defer ts.Close()
+
+	c := NewEmailClient("key", ts.URL, ts.Client(), WithRetryPolicy(RetryPolicy{
+		MaxAttempts:       2,
+		RetryableStatuses: []int{409},
+	}))
+	if _, err := c.Request(context.Background(), "POST", "/email", nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestRetry_NetworkError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Close()
+
+	c := NewEmailClient("key", ts.URL, ts.Client(), fastRetry(3))
+	if _, err := c.Request(context.Background(), "POST", "/email", nil); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestRetry_RetryAfterHeader(t *testing.T) {
+	var calls int32
+	var first, second time.Time
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(429)
+			return
+		}
+		second = time.Now()
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	c := NewEmailClient("key", ts.URL, ts.Client(), WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 2,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+	}))
+	if _, err := c.Request(context.Background(), "POST", "/email", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := second.Sub(first); got < 900*time.Millisecond {
+		t.Errorf("waited %v between attempts, want about 1s", got)
+	}
+}
+
+func TestRetry_RetryAfterAboveMaxBackoff(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	c := NewEmailClient("key", ts.URL, ts.Client(), fastRetry(3))
+	resp, err := c.Request(context.Background(), "POST", "/email", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 503 || calls != 1 {
+		t.Errorf("status = %d, calls = %d, want 503 after 1 call", resp.StatusCode, calls)
+	}
+}
+
+func TestRetry_StopsWhenContextDone(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(500)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c := NewEmailClient("key", ts.URL, ts.Client(), WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 5,
+		BaseBackoff: time.Second,
+	}))
+	start := time.Now()
+	resp, err := c.Request(ctx, "POST", "/email", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 500 {
+		t.Errorf("StatusCode = %d, want 500", resp.StatusCode)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("request took %v, expected it to stop early", time.Since(start))
+	}
+}
+
+func TestRetry_UploadRewindsBody(t *testing.T) {
+	var calls int32
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(502)
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	c := NewEmailClient("key", ts.URL, ts.Client(), fastRetry(2))
+	if _, err := c.Upload(context.Background(), "/files", "a.txt", []byte("file content")); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 || bodies[0] != "file content" || bodies[1] != "file content" {
+		t.Errorf("bodies = %q, want the full content twice", bodies)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := &RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		if got := p.backoff(1); got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("jittered backoff = %v, want between 50ms and 100ms", got)
+		}
+	}
+}
+
+func TestRetryAfter_Parse(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := http.Header{}
+	h.Set("Retry-After", "7")
+	if d, ok := retryAfter(h, now); !ok || d != 7*time.Second {
+		t.Errorf("seconds: got %v, %v", d, ok)
+	}
+	h.Set("Retry-After", now.Add(3*time.Second).Format(http.TimeFormat))
+	if d, ok := retryAfter(h, now); !ok || d != 3*time.Second {
+		t.Errorf("date: got %v, %v", d, ok)
+	}
+	h.Set("Retry-After", "soon")
+	if _, ok := retryAfter(h, now); ok {
+		t.Error("expected invalid value to be ignored")
+	}
+}
@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Token is an OAuth access token with its expiry. A zero Expiry means the
+// token never expires.
+type Token struct {
+	AccessToken string
+	Expiry      time.Time
+}
+
+// TokenSource supplies OAuth access tokens for the templates API.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// tokenExpiryMargin renews tokens slightly before they expire so that a
+// token does not lapse while a request is in flight.
+const tokenExpiryMargin = 30 * time.Second
+
+// WithTokenSource makes the client fetch its OAuth token from src instead of
+// using a static token.
+func WithTokenSource(src TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = &tokenCache{src: src}
+	}
+}
+
+// tokenCache caches the current token of a TokenSource until it expires.
+type tokenCache struct {
+	src TokenSource
+
+	mu  sync.Mutex
+	tok *Token
+}
+
+func (tc *tokenCache) token(ctx context.Context) (string, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.tok != nil && (tc.tok.Expiry.IsZero() || time.Now().Add(tokenExpiryMargin).Before(tc.tok.Expiry)) {
+		return tc.tok.AccessToken, nil
+	}
+	tok, err := tc.src.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	tc.tok = tok
+	return tok.AccessToken, nil
+}
+
+// invalidate drops the cached token if it is still the rejected one, so that
+// concurrent requests failing with the same token trigger a single refresh.
+func (tc *tokenCache) invalidate(rejected string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.tok != nil && tc.tok.AccessToken == rejected {
+		tc.tok = nil
+	}
+}
+
+// tokenRejected reports whether resp rejects the OAuth token itself, as
+// invalid or expired, so that a fresh token may succeed. Other 401s, such as
+// a missing scope, would fail again and are returned as they are.
+func tokenRejected(resp *Response) bool {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		return false
+	}
+	return body.Error.Code == "INVALID_OAUTHTOKEN"
+}
@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingSource struct {
+	calls  int32
+	expiry time.Duration
+	err    error
+}
+
+func (s *countingSource) Token(ctx context.Context) (*Token, error) {
+	n := atomic.AddInt32(&s.calls, 1)
+	if s.err != nil {
+		return nil, s.err
+	}
+	tok := &Token{AccessToken: fmt.Sprintf("tok-%d", n)}
+	if s.expiry != 0 {
+		tok.Expiry = time.Now().Add(s.expiry)
+	}
+	return tok, nil
+}
+
+func TestTokenSource_CachesToken(t *testing.T) {
+	var auths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	src := &countingSource{expiry: time.Hour}
+	c := NewTemplatesClient("", ts.URL, ts.Client(), WithTokenSource(src))
+	for i := 0; i < 3; i++ {
+		if _, err := c.Request(context.Background(), "GET", "/", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if src.calls != 1 {
+		t.Errorf("token fetched %d times, want 1", src.calls)
+	}
+	if auths[2] != "Zoho-oauthtoken tok-1" {
+		t.Errorf("Authorization = %q", auths[2])
+	}
+}
+
+func TestTokenSource_RenewsExpiredToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	// Tokens expiring within the safety margin are treated as expired.
+	src := &countingSource{expiry: tokenExpiryMargin / 2}
+	c := NewTemplatesClient("", ts.URL, ts.Client(), WithTokenSource(src))
+	for i := 0; i < 2; i++ {
+		if _, err := c.Request(context.Background(), "GET", "/", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if src.calls != 2 {
+		t.Errorf("token fetched %d times, want 2", src.calls)
+	}
+}
+
+func TestTokenSource_RefreshesOnceOn401(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("Authorization") == "Zoho-oauthtoken tok-1" {
+			w.WriteHeader(401)
+			w.Write([]byte(`{"error":{"code":"INVALID_OAUTHTOKEN"}}`))
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	src := &countingSource{expiry: time.Hour}
+	c := NewTemplatesClient("", ts.URL, ts.Client(), WithTokenSource(src))
+	resp, err := c.Request(context.Background(), "POST", "/", map[string]string{"a": "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 || calls != 2 || src.calls != 2 {
+		t.Errorf("status %d after %d calls and %d token fetches, want 200, 2, 2", resp.StatusCode, calls, src.calls)
+	}
+}
+
+func TestTokenSource_GivesUpAfterOneRefresh(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(401)
+		w.Write([]byte(`{"error":{"code":"INVALID_OAUTHTOKEN"}}`))
+	}))
+	defer ts.Close()
+
+	c := NewTemplatesClient("", ts.URL, ts.Client(), WithTokenSource(&countingSource{}))
+	resp, err := c.Request(context.Background(), "GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 401 || calls != 2 {
+		t.Errorf("status %d after %d calls, want 401 after 2", resp.StatusCode, calls)
+	}
+}
+
+func TestTokenSource_KeepsTokenOnOther401(t *testing.T) {
+	for _, body := range []string{``, `{"error":{"code":"INVALID_OAUTHSCOPE"}}`, `not json`} {
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(401)
+			w.Write([]byte(body))
+		}))
+
+		src := &countingSource{expiry: time.Hour}
+		c := NewTemplatesClient("", ts.URL, ts.Client(), WithTokenSource(src))
+		resp, err := c.Request(context.Background(), "GET", "/", nil)
+		ts.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != 401 || calls != 1 || src.calls != 1 {
+			t.Errorf("body %q: status %d after %d calls and %d token fetches, want 401, 1, 1", body, resp.StatusCode, calls, src.calls)
+		}
+	}
+}
+
+func TestTokenSource_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not be sent without a token")
+	}))
+	defer ts.Close()
+
+	boom := errors.New("boom")
+	c := NewTemplatesClient("", ts.URL, ts.Client(), WithTokenSource(&countingSource{err: boom}))
+	if _, err := c.Request(context.Background(), "GET", "/", nil); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want boom", err)
+	}
+}
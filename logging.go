@@ -0,0 +1,267 @@
+package zeptomail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go/internal/transport"
+)
+
+// Logger receives structured log records as a message followed by
+// alternating keys and values. *slog.Logger satisfies it, as do most
+// structured loggers with a thin adapter; StdLogger adapts a *log.Logger.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+	WarnContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// LogOption configures WithLogger.
+type LogOption func(*logConfig)
+
+type logConfig struct {
+	recipients bool
+	content    bool
+}
+
+// LogRecipients includes sender and recipient addresses in log records.
+// They are left out by default.
+func LogRecipients() LogOption {
+	return func(lc *logConfig) {
+		lc.recipients = true
+	}
+}
+
+// LogContent includes subjects, bodies and file names in log records. They
+// are left out by default.
+func LogContent() LogOption {
+	return func(lc *logConfig) {
+		lc.content = true
+	}
+}
+
+// WithLogger logs every client operation to l once it completes: at Info
+// level on success and Error level on failure, with the operation, method,
+// path, HTTP status, attempts, latency, request_id and, for failures, the
+// error code. The request headers are logged at Debug level with the
+// Authorization header always redacted.
+//
+// Recipient addresses, subjects and bodies are redacted unless enabled with
+// LogRecipients and LogContent; only counts and sizes are logged. Without
+// LogRecipients, addresses quoted in error messages are redacted as well.
+func WithLogger(l Logger, opts ...LogOption) Option {
+	lc := logConfig{}
+	for _, opt := range opts {
+		opt(&lc)
+	}
+	return func(cfg *clientConfig) {
+		cfg.logger = loggingMiddleware(l, lc)
+	}
+}
+
+func loggingMiddleware(l Logger, lc logConfig) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (interface{}, error) {
+			start := time.Now()
+			out, err := next(ctx, call)
+
+			args := []interface{}{"operation", call.Operation, "method", call.Method, "path", call.Path}
+			ex := transport.ExchangeFrom(ctx)
+			if ex != nil && ex.StatusCode != 0 {
+				args = append(args, "status", ex.StatusCode)
+			}
+			if ex != nil && ex.Attempts > 1 {
+				args = append(args, "attempts", ex.Attempts)
+			}
+			args = append(args, "latency", time.Since(start))
+			if id := requestID(out, err); id != "" {
+				args = append(args, "request_id", id)
+			}
+			args = append(args, lc.payload(call.Payload)...)
+
+			if err != nil {
+				args = append(args, lc.errorAttrs(err)...)
+				l.ErrorContext(ctx, "zeptomail request failed", args...)
+			} else {
+				l.InfoContext(ctx, "zeptomail request", args...)
+			}
+			if ex != nil && ex.RequestHeader != nil {
+				l.DebugContext(ctx, "zeptomail request headers", "operation", call.Operation, "headers", redactHeader(ex.RequestHeader))
+			}
+			return out, err
+		}
+	}
+}
+
+func requestID(out interface{}, err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RequestID
+	}
+	if resp, ok := out.(*SuccessResponse); ok {
+		return resp.RequestID
+	}
+	return ""
+}
+
+// payload describes a request payload without personal data unless enabled.
+func (lc logConfig) payload(p interface{}) []interface{} {
+	var args []interface{}
+	switch p := p.(type) {
+	case *EmailRequest:
+		args = append(args, "recipients", len(p.To)+len(p.Cc)+len(p.Bcc), "attachments", len(p.Attachments)+len(p.InlineImages))
+		args = append(args, lc.addresses(p.From, p.To, p.Cc, p.Bcc)...)
+		if lc.content {
+			args = append(args, "subject", p.Subject, "htmlbody", p.HTMLBody, "textbody", p.TextBody)
+		}
+	case *TemplateRequest:
+		args = append(args, "template_key", p.TemplateKey, "template_alias", p.TemplateAlias)
+		args = append(args, "recipients", len(p.To)+len(p.Cc)+len(p.Bcc), "attachments", len(p.Attachments)+len(p.InlineImages))
+		args = append(args, lc.addresses(p.From, p.To, p.Cc, p.Bcc)...)
+		if lc.content && p.Subject != "" {
+			args = append(args, "subject", p.Subject)
+		}
+	case *FileUploadRequest:
+		args = append(args, "bytes", p.size())
+		if lc.content {
+			args = append(args, "filename", p.Filename)
+		}
+	case *CreateTemplateRequest:
+		args = append(args, "template_name", p.TemplateName)
+		if lc.content {
+			args = append(args, "subject", p.Subject, "htmlbody", p.HTMLBody, "textbody", p.TextBody)
+		}
+	}
+	return args
+}
+
+func (lc logConfig) addresses(from EmailAddress, lists ...[]Recipient) []interface{} {
+	if !lc.recipients {
+		return nil
+	}
+	var to []string
+	for _, list := range lists {
+		for _, r := range list {
+			to = append(to, r.Address)
+		}
+	}
+	return []interface{}{"from", from.Address, "to", to}
+}
+
+// emailAddress matches anything shaped like an email address in free text.
+var emailAddress = regexp.MustCompile(`[^\s<>()\[\],;:"'@]+@[^\s<>()\[\],;:"'@]+`)
+
+// redact replaces email addresses in s unless LogRecipients is enabled.
+// API and SMTP error messages often name the rejected recipient.
+func (lc logConfig) redact(s string) string {
+	if lc.recipients {
+		return s
+	}
+	return emailAddress.ReplaceAllString(s, "REDACTED")
+}
+
+// errorAttrs describes err. Validation messages quote the offending values,
+// so without LogRecipients only the invalid fields are logged.
+func (lc logConfig) errorAttrs(err error) []interface{} {
+	var apiErr *APIError
+	var verr *ValidationError
+	switch {
+	case errors.As(err, &apiErr):
+		return []interface{}{"error_code", apiErr.Code, "error", lc.redact(apiErr.Message)}
+	case errors.As(err, &verr):
+		targets := make([]string, len(verr.Details))
+		for i, d := range verr.Details {
+			targets[i] = d.Target
+		}
+		args := []interface{}{"error_code", "VALIDATION", "invalid_fields", targets}
+		if lc.recipients && lc.content {
+			args = append(args, "error", verr.Error())
+		}
+		return args
+	default:
+		return []interface{}{"error", lc.redact(err.Error())}
+	}
+}
+
+// redactHeader returns a copy of h with credentials replaced.
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, key := range []string{"Authorization", "Proxy-Authorization", "Cookie"} {
+		if _, ok := h[key]; ok {
+			h[key] = []string{"REDACTED"}
+		}
+	}
+	return h
+}
+
+// StdLogger adapts a *log.Logger, writing each record on one line as the
+// level, the message and key=value pairs. Debug records are dropped unless
+// debug is true.
+func StdLogger(l *log.Logger, debug bool) Logger {
+	return stdLogger{l: l, debug: debug}
+}
+
+type stdLogger struct {
+	l     *log.Logger
+	debug bool
+}
+
+func (s stdLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	if s.debug {
+		s.log("DEBUG", msg, args)
+	}
+}
+
+func (s stdLogger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	s.log("INFO", msg, args)
+}
+
+func (s stdLogger) WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	s.log("WARN", msg, args)
+}
+
+func (s stdLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	s.log("ERROR", msg, args)
+}
+
+func (s stdLogger) log(level, msg string, args []interface{}) {
+	var b strings.Builder
+	b.WriteString(level)
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, " %v=%s", args[i], formatLogValue(args[i+1]))
+	}
+	s.l.Print(b.String())
+}
+
+func formatLogValue(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case http.Header:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + ":" + strings.Join(v[k], ",")
+		}
+		s = strings.Join(parts, "; ")
+	default:
+		s = fmt.Sprint(v)
+	}
+	if strings.ContainsAny(s, " \t\"=") || s == "" {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
@@ -0,0 +1,195 @@
+package zeptomail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type logRecord struct {
+	level string
+	msg   string
+	attrs map[string]interface{}
+}
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	records []logRecord
+}
+
+func (l *recordingLogger) add(level, msg string, args []interface{}) {
+	attrs := make(map[string]interface{})
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs[args[i].(string)] = args[i+1]
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, logRecord{level, msg, attrs})
+}
+
+func (l *recordingLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	l.add("DEBUG", msg, args)
+}
+
+func (l *recordingLogger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	l.add("INFO", msg, args)
+}
+
+func (l *recordingLogger) WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	l.add("WARN", msg, args)
+}
+
+func (l *recordingLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	l.add("ERROR", msg, args)
+}
+
+// dump renders all records as text, to check nothing sensitive leaks.
+func (l *recordingLogger) dump() string {
+	var b strings.Builder
+	for _, r := range l.records {
+		fmt.Fprintf(&b, "%s %s %v\n", r.level, r.msg, r.attrs)
+	}
+	return b.String()
+}
+
+func TestWithLogger_Success(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"request_id":"req-1","message":"OK"}`))
+	}))
+	defer ts.Close()
+
+	logger := &recordingLogger{}
+	client := NewEmailClient("secret-api-key", WithBaseURL(ts.URL), WithLogger(logger))
+	req := testEmailRequest()
+	req.Subject = "Your password reset code"
+	if _, err := client.SendEmail(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logger.records) != 2 {
+		t.Fatalf("got %d records, want 2:\n%s", len(logger.records), logger.dump())
+	}
+	info := logger.records[0]
+	if info.level != "INFO" {
+		t.Errorf("level = %s", info.level)
+	}
+	for key, want := range map[string]interface{}{
+		"operation":  "SendEmail",
+		"method":     "POST",
+		"path":       "/email",
+		"status":     200,
+		"request_id": "req-1",
+		"recipients": 1,
+	} {
+		if got := info.attrs[key]; got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+	if _, ok := info.attrs["latency"]; !ok {
+		t.Error("latency missing")
+	}
+
+	debug := logger.records[1]
+	if h := debug.attrs["headers"].(http.Header); debug.level != "DEBUG" || h.Get("Authorization") != "REDACTED" {
+		t.Errorf("debug record = %+v", debug)
+	}
+	if out := logger.dump(); strings.Contains(out, "secret-api-key") || strings.Contains(out, "c@d.com") || strings.Contains(out, "password reset") {
+		t.Errorf("sensitive data logged:\n%s", out)
+	}
+}
+
+func TestWithLogger_OptIn(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"request_id":"req-1"}`))
+	}))
+	defer ts.Close()
+
+	logger := &recordingLogger{}
+	client := NewEmailClient("secret-api-key", WithBaseURL(ts.URL), WithLogger(logger, LogRecipients(), LogContent()))
+	if _, err := client.SendEmail(context.Background(), testEmailRequest()); err != nil {
+		t.Fatal(err)
+	}
+	out := logger.dump()
+	for _, want := range []string{"a@b.com", "c@d.com", "subject:hi", "textbody:hello"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q not logged:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "secret-api-key") {
+		t.Errorf("API key logged:\n%s", out)
+	}
+}
+
+func TestWithLogger_Errors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"code":"SERR_157","message":"Invalid API Token found","request_id":"req-2"}}`))
+	}))
+	defer ts.Close()
+
+	logger := &recordingLogger{}
+	client := NewEmailClient("bad-key", WithBaseURL(ts.URL), WithLogger(logger))
+	if _, err := client.SendEmail(context.Background(), testEmailRequest()); err == nil {
+		t.Fatal("expected an error")
+	}
+	rec := logger.records[0]
+	if rec.level != "ERROR" || rec.attrs["error_code"] != "SERR_157" || rec.attrs["status"] != 401 || rec.attrs["request_id"] != "req-2" {
+		t.Errorf("record = %+v", rec)
+	}
+
+	// Validation messages quote the invalid address, so only fields are logged.
+	logger.records = nil
+	req := testEmailRequest()
+	req.To[0].Address = "not-an-address"
+	if _, err := client.SendEmail(context.Background(), req); err == nil {
+		t.Fatal("expected a validation error")
+	}
+	rec = logger.records[0]
+	if rec.attrs["error_code"] != "VALIDATION" || strings.Contains(logger.dump(), "not-an-address") {
+		t.Errorf("record = %+v", rec)
+	}
+	if fields := rec.attrs["invalid_fields"].([]string); len(fields) != 1 || fields[0] != "to[0].email_address.address" {
+		t.Errorf("invalid_fields = %v", fields)
+	}
+}
+
+func TestWithLogger_RedactsAddressesInErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":"TM_3301","message":"Recipient <jane.doe@example.org> rejected"}}`))
+	}))
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		opts []LogOption
+		want string
+	}{
+		{nil, "Recipient <REDACTED> rejected"},
+		{[]LogOption{LogRecipients()}, "Recipient <jane.doe@example.org> rejected"},
+	} {
+		logger := &recordingLogger{}
+		client := NewEmailClient("key", WithBaseURL(ts.URL), WithLogger(logger, tt.opts...))
+		if _, err := client.SendEmail(context.Background(), testEmailRequest()); err == nil {
+			t.Fatal("expected an error")
+		}
+		if got := logger.records[0].attrs["error"]; got != tt.want {
+			t.Errorf("error = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := StdLogger(log.New(&buf, "", 0), false)
+	l.InfoContext(context.Background(), "zeptomail request", "operation", "SendEmail", "status", 200, "error", "bad thing")
+	l.DebugContext(context.Background(), "hidden")
+	if got := buf.String(); got != "INFO zeptomail request operation=SendEmail status=200 error=\"bad thing\"\n" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,284 @@
+package zeptomail
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go/internal/transport"
+)
+
+// CallStats describes the outcome of one client operation.
+type CallStats struct {
+	Operation string
+	Duration  time.Duration
+	// StatusCode is the HTTP status of the last response, or zero if none
+	// was received.
+	StatusCode int
+	// Attempts is the number of requests sent, retries included.
+	Attempts int
+	// Recipients and UploadBytes count the recipients and the decoded
+	// attachment or file bytes of a successful send or upload. UploadBytes
+	// is zero for a streamed upload of unknown size.
+	Recipients  int
+	UploadBytes int64
+	// ErrorCode is empty on success. See ErrorCode for its values.
+	ErrorCode string
+}
+
+// Metrics receives the stats of every client operation. Implementations
+// must be safe for concurrent use. MetricsCollector is an in-memory
+// implementation.
+type Metrics interface {
+	RecordCall(ctx context.Context, stats CallStats)
+}
+
+// WithMetrics reports the stats of every operation to m.
+func WithMetrics(m Metrics) Option {
+	return func(cfg *clientConfig) {
+		cfg.metrics = metricsMiddleware(m)
+	}
+}
+
+func metricsMiddleware(m Metrics) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (interface{}, error) {
+			start := time.Now()
+			out, err := next(ctx, call)
+
+			stats := CallStats{Operation: call.Operation, Duration: time.Since(start)}
+			if ex := transport.ExchangeFrom(ctx); ex != nil {
+				stats.StatusCode = ex.StatusCode
+				stats.Attempts = ex.Attempts
+			}
+			if err != nil {
+				stats.ErrorCode = ErrorCode(err)
+			} else {
+				stats.Recipients, stats.UploadBytes, _ = payloadCounts(call.Payload)
+			}
+			m.RecordCall(ctx, stats)
+			return out, err
+		}
+	}
+}
+
+// ErrorCode returns a short code for err suitable as a metric label: the
+// Code of an *APIError (or "HTTP_<status>" if it has none), "VALIDATION"
+// for a *ValidationError, "CANCELED" when the context was cancelled or
+// timed out, and "TRANSPORT" for anything else, such as network errors.
+func ErrorCode(err error) string {
+	var apiErr *APIError
+	var valErr *ValidationError
+	switch {
+	case errors.As(err, &apiErr):
+		if apiErr.Code != "" {
+			return apiErr.Code
+		}
+		return "HTTP_" + strconv.Itoa(apiErr.HTTPStatusCode)
+	case errors.As(err, &valErr):
+		return "VALIDATION"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "CANCELED"
+	}
+	return "TRANSPORT"
+}
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency
+// histogram buckets of a MetricsCollector.
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// MetricsCollector is an in-memory Metrics that keeps counters and a
+// latency histogram per operation. It can be read with Snapshot, published
+// with expvar, and served in the Prometheus text format as an http.Handler.
+type MetricsCollector struct {
+	mu          sync.Mutex
+	buckets     []float64
+	ops         map[string]*OperationMetrics
+	writeErrors int64
+}
+
+// NewMetricsCollector returns an empty collector using a copy of
+// DefaultLatencyBuckets, so later changes to that variable do not affect it.
+func NewMetricsCollector() *MetricsCollector {
+	return &MetricsCollector{
+		buckets: append([]float64(nil), DefaultLatencyBuckets...),
+		ops:     make(map[string]*OperationMetrics),
+	}
+}
+
+// MetricsSnapshot is a copy of the metrics of a MetricsCollector, keyed by
+// operation name.
+type MetricsSnapshot struct {
+	Operations map[string]OperationMetrics `json:"operations"`
+	// WriteErrors counts ServeHTTP responses that could not be written.
+	WriteErrors int64 `json:"write_errors,omitempty"`
+}
+
+// OperationMetrics holds the metrics of one operation.
+type OperationMetrics struct {
+	Requests int64 `json:"requests"`
+	// Retries counts the attempts beyond the first.
+	Retries       int64 `json:"retries"`
+	Recipients    int64 `json:"recipients"`
+	BytesUploaded int64 `json:"bytes_uploaded"`
+	// Errors counts failed requests by ErrorCode.
+	Errors  map[string]int64 `json:"errors,omitempty"`
+	Latency Histogram        `json:"latency"`
+}
+
+// Histogram counts observations in buckets. Counts[i] is the number of
+// observations at most Bounds[i] and above Bounds[i-1]; the last element of
+// Counts, one past Bounds, counts the rest.
+type Histogram struct {
+	Bounds []float64 `json:"bounds"`
+	Counts []int64   `json:"counts"`
+	Count  int64     `json:"count"`
+	Sum    float64   `json:"sum"`
+}
+
+func (h *Histogram) observe(v float64) {
+	i := sort.SearchFloat64s(h.Bounds, v)
+	h.Counts[i]++
+	h.Count++
+	h.Sum += v
+}
+
+// RecordCall adds stats to the collector.
+func (m *MetricsCollector) RecordCall(ctx context.Context, stats CallStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op, ok := m.ops[stats.Operation]
+	if !ok {
+		op = &OperationMetrics{Latency: Histogram{
+			Bounds: m.buckets,
+			Counts: make([]int64, len(m.buckets)+1),
+		}}
+		m.ops[stats.Operation] = op
+	}
+	op.Requests++
+	if stats.Attempts > 1 {
+		op.Retries += int64(stats.Attempts - 1)
+	}
+	op.Recipients += int64(stats.Recipients)
+	op.BytesUploaded += stats.UploadBytes
+	if stats.ErrorCode != "" {
+		if op.Errors == nil {
+			op.Errors = make(map[string]int64)
+		}
+		op.Errors[stats.ErrorCode]++
+	}
+	op.Latency.observe(stats.Duration.Seconds())
+}
+
+// Snapshot returns a copy of the current metrics.
+func (m *MetricsCollector) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := MetricsSnapshot{
+		Operations:  make(map[string]OperationMetrics, len(m.ops)),
+		WriteErrors: m.writeErrors,
+	}
+	for name, op := range m.ops {
+		c := *op
+		c.Latency.Bounds = append([]float64(nil), op.Latency.Bounds...)
+		c.Latency.Counts = append([]int64(nil), op.Latency.Counts...)
+		if op.Errors != nil {
+			c.Errors = make(map[string]int64, len(op.Errors))
+			for code, n := range op.Errors {
+				c.Errors[code] = n
+			}
+		}
+		s.Operations[name] = c
+	}
+	return s
+}
+
+// Publish exports the snapshot as the expvar variable name, served as JSON
+// under /debug/vars. Like expvar.Publish, it panics if name is already in
+// use.
+func (m *MetricsCollector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} { return m.Snapshot() }))
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+// Failed writes, typically a scraper that went away, are counted in
+// zeptomail_metrics_write_errors_total.
+func (m *MetricsCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := m.WritePrometheus(w); err != nil {
+		m.mu.Lock()
+		m.writeErrors++
+		m.mu.Unlock()
+	}
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition
+// format. Metric names are prefixed with "zeptomail_" and labelled by
+// operation.
+func (m *MetricsCollector) WritePrometheus(w io.Writer) error {
+	s := m.Snapshot()
+	names := make([]string, 0, len(s.Operations))
+	for name := range s.Operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	counter := func(metric, help string, value func(OperationMetrics) int64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", metric, help, metric)
+		for _, name := range names {
+			fmt.Fprintf(&b, "%s{operation=%s} %d\n", metric, promLabel(name), value(s.Operations[name]))
+		}
+	}
+	counter("zeptomail_requests_total", "Client operations performed.", func(op OperationMetrics) int64 { return op.Requests })
+	counter("zeptomail_retries_total", "Requests retried.", func(op OperationMetrics) int64 { return op.Retries })
+	counter("zeptomail_recipients_total", "Recipients of successful sends.", func(op OperationMetrics) int64 { return op.Recipients })
+	counter("zeptomail_uploaded_bytes_total", "Attachment and file bytes successfully uploaded.", func(op OperationMetrics) int64 { return op.BytesUploaded })
+
+	b.WriteString("# HELP zeptomail_errors_total Failed operations by error code.\n# TYPE zeptomail_errors_total counter\n")
+	for _, name := range names {
+		errs := s.Operations[name].Errors
+		codes := make([]string, 0, len(errs))
+		for code := range errs {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Fprintf(&b, "zeptomail_errors_total{operation=%s,code=%s} %d\n", promLabel(name), promLabel(code), errs[code])
+		}
+	}
+
+	b.WriteString("# HELP zeptomail_request_duration_seconds Latency of client operations.\n# TYPE zeptomail_request_duration_seconds histogram\n")
+	for _, name := range names {
+		h := s.Operations[name].Latency
+		op := promLabel(name)
+		var cum int64
+		for i, bound := range h.Bounds {
+			cum += h.Counts[i]
+			fmt.Fprintf(&b, "zeptomail_request_duration_seconds_bucket{operation=%s,le=\"%s\"} %d\n", op, strconv.FormatFloat(bound, 'g', -1, 64), cum)
+		}
+		fmt.Fprintf(&b, "zeptomail_request_duration_seconds_bucket{operation=%s,le=\"+Inf\"} %d\n", op, h.Count)
+		fmt.Fprintf(&b, "zeptomail_request_duration_seconds_sum{operation=%s} %s\n", op, strconv.FormatFloat(h.Sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "zeptomail_request_duration_seconds_count{operation=%s} %d\n", op, h.Count)
+	}
+
+	fmt.Fprintf(&b, "# HELP zeptomail_metrics_write_errors_total Metrics responses that could not be written.\n# TYPE zeptomail_metrics_write_errors_total counter\nzeptomail_metrics_write_errors_total %d\n", s.WriteErrors)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabel quotes a Prometheus label value.
+func promLabel(v string) string {
+	return `"` + promEscaper.Replace(v) + `"`
+}
@@ -0,0 +1,184 @@
+package zeptomail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithMetrics(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.Write([]byte(`{"request_id":"req-1","message":"OK"}`))
+		case 2, 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"code":"TM_5001","message":"Service unavailable"}}`))
+		}
+	}))
+	defer ts.Close()
+
+	m := NewMetricsCollector()
+	client := NewEmailClient("test-api-key", WithBaseURL(ts.URL), WithMetrics(m),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond}))
+	req := testEmailRequest()
+	req.Bcc = []Recipient{{EmailAddress: EmailAddress{Address: "e@f.com"}}}
+	req.Attachments = []Attachment{{Name: "a.txt", Content: "aGVsbG8="}}
+	if _, err := client.SendEmail(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SendEmail(context.Background(), req); err == nil {
+		t.Fatal("expected error")
+	}
+	req.Subject = ""
+	if _, err := client.SendEmail(context.Background(), req); err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	op := m.Snapshot().Operations["SendEmail"]
+	if op.Requests != 3 || op.Retries != 1 || op.Recipients != 2 || op.BytesUploaded != 5 {
+		t.Errorf("metrics = %+v", op)
+	}
+	if op.Errors["TM_5001"] != 1 || op.Errors["VALIDATION"] != 1 {
+		t.Errorf("errors = %v", op.Errors)
+	}
+	if op.Latency.Count != 3 || len(op.Latency.Counts) != len(DefaultLatencyBuckets)+1 {
+		t.Errorf("latency = %+v", op.Latency)
+	}
+}
+
+func TestWithMetrics_UploadOfUnknownSize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{"file_cache_key":"fck-1"}`))
+	}))
+	defer ts.Close()
+
+	m := NewMetricsCollector()
+	client := NewEmailClient("test-api-key", WithBaseURL(ts.URL), WithMetrics(m))
+	for _, size := range []int64{-1, 4} {
+		if _, err := client.FileCacheUploadReader(context.Background(), "a.txt", strings.NewReader("data"), size); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if op := m.Snapshot().Operations["FileCacheUpload"]; op.Requests != 2 || op.BytesUploaded != 4 {
+		t.Errorf("metrics = %+v, want 2 requests and 4 bytes", op)
+	}
+}
+
+func TestMetricsCollector_Prometheus(t *testing.T) {
+	m := NewMetricsCollector()
+	m.RecordCall(context.Background(), CallStats{Operation: "SendEmail", Duration: 80 * time.Millisecond, Attempts: 1, Recipients: 3})
+	m.RecordCall(context.Background(), CallStats{Operation: "SendEmail", Duration: 2 * time.Second, Attempts: 3, ErrorCode: "TM_4001"})
+	m.RecordCall(context.Background(), CallStats{Operation: "FileCacheUpload", Duration: time.Minute, Attempts: 1, UploadBytes: 1024})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE zeptomail_requests_total counter\n",
+		`zeptomail_requests_total{operation="FileCacheUpload"} 1` + "\n",
+		`zeptomail_requests_total{operation="SendEmail"} 2` + "\n",
+		`zeptomail_retries_total{operation="SendEmail"} 2` + "\n",
+		`zeptomail_recipients_total{operation="SendEmail"} 3` + "\n",
+		`zeptomail_uploaded_bytes_total{operation="FileCacheUpload"} 1024` + "\n",
+		`zeptomail_errors_total{operation="SendEmail",code="TM_4001"} 1` + "\n",
+		"# TYPE zeptomail_request_duration_seconds histogram\n",
+		`zeptomail_request_duration_seconds_bucket{operation="SendEmail",le="0.05"} 0` + "\n",
+		`zeptomail_request_duration_seconds_bucket{operation="SendEmail",le="0.1"} 1` + "\n",
+		`zeptomail_request_duration_seconds_bucket{operation="SendEmail",le="2.5"} 2` + "\n",
+		`zeptomail_request_duration_seconds_bucket{operation="FileCacheUpload",le="30"} 0` + "\n",
+		`zeptomail_request_duration_seconds_bucket{operation="FileCacheUpload",le="+Inf"} 1` + "\n",
+		`zeptomail_request_duration_seconds_sum{operation="SendEmail"} 2.08` + "\n",
+		`zeptomail_request_duration_seconds_count{operation="SendEmail"} 2` + "\n",
+		"zeptomail_metrics_write_errors_total 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsCollector_Publish(t *testing.T) {
+	m := NewMetricsCollector()
+	m.RecordCall(context.Background(), CallStats{Operation: "SendEmail", Duration: time.Millisecond, Attempts: 1, ErrorCode: "TM_3201"})
+	m.Publish("zeptomail_test")
+
+	var s MetricsSnapshot
+	if err := json.Unmarshal([]byte(expvar.Get("zeptomail_test").String()), &s); err != nil {
+		t.Fatal(err)
+	}
+	if op := s.Operations["SendEmail"]; op.Requests != 1 || op.Errors["TM_3201"] != 1 {
+		t.Errorf("published %+v", s)
+	}
+}
+
+func TestMetricsCollector_SnapshotIsCopy(t *testing.T) {
+	m := NewMetricsCollector()
+	m.RecordCall(context.Background(), CallStats{Operation: "SendEmail", ErrorCode: "TM_3201"})
+	s := m.Snapshot()
+	m.RecordCall(context.Background(), CallStats{Operation: "SendEmail", ErrorCode: "TM_3201"})
+	if op := s.Operations["SendEmail"]; op.Requests != 1 || op.Errors["TM_3201"] != 1 || op.Latency.Counts[0] != 1 {
+		t.Errorf("snapshot changed: %+v", op)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&APIError{HTTPStatusCode: 400, Code: "TM_3201"}, "TM_3201"},
+		{fmt.Errorf("wrapped: %w", &APIError{HTTPStatusCode: 502}), "HTTP_502"},
+		{&ValidationError{}, "VALIDATION"},
+		{context.DeadlineExceeded, "CANCELED"},
+		{errors.New("connection refused"), "TRANSPORT"},
+	}
+	for _, tt := range tests {
+		if got := ErrorCode(tt.err); got != tt.want {
+			t.Errorf("ErrorCode(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+type failingResponseWriter struct{ header http.Header }
+
+func (w failingResponseWriter) Header() http.Header     { return w.header }
+func (failingResponseWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+func (failingResponseWriter) WriteHeader(int)           {}
+
+func TestMetricsCollector_CountsWriteErrors(t *testing.T) {
+	m := NewMetricsCollector()
+	m.ServeHTTP(failingResponseWriter{http.Header{}}, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if n := m.Snapshot().WriteErrors; n != 1 {
+		t.Errorf("WriteErrors = %d, want 1", n)
+	}
+}
+
+func TestMetricsCollector_OwnsBuckets(t *testing.T) {
+	saved := DefaultLatencyBuckets
+	defer func() { DefaultLatencyBuckets = saved }()
+	DefaultLatencyBuckets = []float64{1, 2}
+	m := NewMetricsCollector()
+	DefaultLatencyBuckets[0] = 100
+
+	m.RecordCall(context.Background(), CallStats{Operation: "SendEmail", Duration: 1500 * time.Millisecond})
+	s := m.Snapshot()
+	s.Operations["SendEmail"].Latency.Bounds[1] = 100
+	if h := m.Snapshot().Operations["SendEmail"].Latency; h.Bounds[0] != 1 || h.Bounds[1] != 2 || h.Counts[1] != 1 {
+		t.Errorf("histogram = %+v, want bounds [1 2] with the call in the second bucket", h)
+	}
+}
@@ -0,0 +1,489 @@
+package zeptomail
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MIMEOption configures WriteMIME and ToEML.
+type MIMEOption func(*mimeMessage)
+
+// mimeMessage holds what writeMIME needs beyond the request itself.
+type mimeMessage struct {
+	messageID string
+	date      time.Time
+	recipient string
+}
+
+// ForRecipient renders the message as the given To recipient receives it
+// from a batch send: addressed to that recipient only, with their MergeInfo
+// applied over the request's.
+func ForRecipient(address string) MIMEOption {
+	return func(m *mimeMessage) {
+		m.recipient = address
+	}
+}
+
+// WithMessageID sets the Message-ID header, e.g. "<id@example.com>", instead
+// of generating a random one.
+func WithMessageID(id string) MIMEOption {
+	return func(m *mimeMessage) {
+		m.messageID = id
+	}
+}
+
+// WithDate sets the Date header instead of using the current time.
+func WithDate(t time.Time) MIMEOption {
+	return func(m *mimeMessage) {
+		m.date = t
+	}
+}
+
+// WriteMIME writes the request as an RFC 5322 message, e.g. to archive what
+// was sent or to preview it in a mail client. Non-ASCII names and subjects
+// are written as encoded-words, bodies as quoted-printable and attachments
+// and inline images as base64, the latter with their Content-ID. Merge
+// fields ({{name}}) in the subject and bodies are filled in from MergeInfo.
+//
+// Attachments and images referencing the file cache cannot be rendered and
+// are reported as a *ValidationError, as are requests for a recipient who
+// is not in To.
+func (r *EmailRequest) WriteMIME(w io.Writer, opts ...MIMEOption) error {
+	m := mimeMessage{}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	if m.messageID == "" {
+		m.messageID = newMessageID(r.From.Address)
+	}
+	if m.date.IsZero() {
+		m.date = time.Now()
+	}
+	return writeMIME(w, r, m)
+}
+
+// ToEML returns the message written by WriteMIME, ready to be saved as an
+// .eml file.
+func (r *EmailRequest) ToEML(opts ...MIMEOption) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.WriteMIME(&buf, opts...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeMIME renders r as an RFC 5322 message: multipart/alternative for the
+// HTML and text bodies, multipart/related for inline images and
+// multipart/mixed for attachments, each only when needed. Bcc recipients
+// are left out of the headers.
+func writeMIME(w io.Writer, r *EmailRequest, m mimeMessage) error {
+	if err := checkRenderable(r); err != nil {
+		return err
+	}
+	r, err := merged(r, m.recipient)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	h := newHeaderWriter(bw)
+	h.set("From", formatAddress(r.From))
+	h.set("To", formatRecipients(r.To))
+	h.set("Cc", formatRecipients(r.Cc))
+	h.set("Reply-To", formatAddresses(r.ReplyTo))
+	h.set("Subject", mime.QEncoding.Encode("utf-8", r.Subject))
+	h.set("Date", m.date.Format(time.RFC1123Z))
+	h.set("Message-ID", m.messageID)
+	h.set("MIME-Version", "1.0")
+	h.custom(r.MimeHeaders)
+
+	root := rootPart(r)
+	if err := root.write(h, bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func checkRenderable(r *EmailRequest) error {
+	v := &validation{}
+	for i, a := range r.Attachments {
+		if a.Content == "" {
+			v.add(ValidationInvalid, fmt.Sprintf("attachments[%d].file_cache_key", i), "file cache attachments cannot be rendered to MIME")
+		}
+	}
+	for i, img := range r.InlineImages {
+		if img.Content == "" {
+			v.add(ValidationInvalid, fmt.Sprintf("inline_images[%d].file_cache_key", i), "file cache images cannot be rendered to MIME")
+		}
+	}
+	// Header names are written as they are, so an invalid one could
+	// inject headers.
+	v.headers(r.MimeHeaders)
+	return v.err()
+}
+
+// merged returns a copy of r with its merge fields filled in, addressed to
+// recipient alone if one is given.
+func merged(r *EmailRequest, recipient string) (*EmailRequest, error) {
+	vars := r.MergeInfo
+	if recipient != "" {
+		i := findRecipient(r.To, recipient)
+		if i < 0 {
+			return nil, &ValidationError{Details: []ErrorDetail{{
+				Code:    ValidationInvalid,
+				Target:  "to",
+				Message: fmt.Sprintf("%q is not a To recipient", recipient),
+			}}}
+		}
+		to := r.To[i]
+		vars = make(map[string]string, len(r.MergeInfo)+len(to.MergeInfo))
+		for k, v := range r.MergeInfo {
+			vars[k] = v
+		}
+		for k, v := range to.MergeInfo {
+			vars[k] = v
+		}
+		cp := *r
+		cp.To = []Recipient{to}
+		r = &cp
+	}
+	if len(vars) == 0 {
+		return r, nil
+	}
+	cp := *r
+	cp.Subject = applyMerge(r.Subject, vars)
+	cp.HTMLBody = applyMerge(r.HTMLBody, vars)
+	cp.TextBody = applyMerge(r.TextBody, vars)
+	return &cp, nil
+}
+
+func findRecipient(rs []Recipient, address string) int {
+	for i, r := range rs {
+		if strings.EqualFold(r.Address, address) {
+			return i
+		}
+	}
+	return -1
+}
+
+var mergeField = regexp.MustCompile(`{{\s*([^{}\s]+)\s*}}`)
+
+// applyMerge replaces {{name}} placeholders with their values, leaving
+// unknown ones as they are.
+func applyMerge(s string, vars map[string]string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return mergeField.ReplaceAllStringFunc(s, func(field string) string {
+		if v, ok := vars[mergeField.FindStringSubmatch(field)[1]]; ok {
+			return v
+		}
+		return field
+	})
+}
+
+// newMessageID returns a random Message-ID in the domain of the sender.
+func newMessageID(from string) string {
+	domain := "zeptomail.invalid"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+	var b [16]byte
+	rand.Read(b[:])
+	return "<" + hex.EncodeToString(b[:]) + "@" + domain + ">"
+}
+
+func formatAddress(a EmailAddress) string {
+	return (&mail.Address{Name: a.Name, Address: a.Address}).String()
+}
+
+func formatAddresses(addrs []EmailAddress) string {
+	s := make([]string, len(addrs))
+	for i, a := range addrs {
+		s[i] = formatAddress(a)
+	}
+	return strings.Join(s, ", ")
+}
+
+func formatRecipients(rs []Recipient) string {
+	s := make([]string, len(rs))
+	for i, r := range rs {
+		s[i] = formatAddress(r.EmailAddress)
+	}
+	return strings.Join(s, ", ")
+}
+
+// headerWriter writes the top-level headers. Headers set by the request's
+// MimeHeaders replace the generated ones of the same name.
+type headerWriter struct {
+	w      *bufio.Writer
+	fields []string
+	values map[string]string
+}
+
+func newHeaderWriter(w *bufio.Writer) *headerWriter {
+	return &headerWriter{w: w, values: make(map[string]string)}
+}
+
+func (h *headerWriter) set(name, value string) {
+	if value == "" {
+		return
+	}
+	key := textproto.CanonicalMIMEHeaderKey(name)
+	if _, ok := h.values[key]; !ok {
+		h.fields = append(h.fields, name)
+	}
+	h.values[key] = value
+}
+
+func (h *headerWriter) custom(headers map[string]string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h.set(name, mime.QEncoding.Encode("utf-8", headers[name]))
+	}
+}
+
+// flush writes the collected headers followed by extra, which holds the
+// Content-* headers of the root part, and the blank line ending them.
+func (h *headerWriter) flush(extra textproto.MIMEHeader) error {
+	for _, name := range h.fields {
+		key := textproto.CanonicalMIMEHeaderKey(name)
+		if _, ok := extra[key]; ok {
+			continue
+		}
+		h.w.WriteString(foldHeader(name + ": " + h.values[key]))
+	}
+	for _, key := range sortedKeys(extra) {
+		for _, v := range extra[key] {
+			fmt.Fprintf(h.w, "%s: %s\r\n", key, v)
+		}
+	}
+	_, err := h.w.WriteString("\r\n")
+	return err
+}
+
+// foldHeader breaks a header line at spaces so that lines stay within 78
+// characters where possible, and terminates it with CRLF.
+func foldHeader(line string) string {
+	const limit = 78
+	var b strings.Builder
+	// The first fold may come right after the colon at the earliest, which
+	// long encoded-words need.
+	first := strings.IndexByte(line, ':') + 1
+	for len(line) > limit {
+		i := strings.LastIndexByte(line[:limit+1], ' ')
+		if i < first {
+			if i = strings.IndexByte(line[limit:], ' '); i < 0 {
+				break
+			}
+			i += limit
+		}
+		first = 1
+		b.WriteString(line[:i])
+		b.WriteString("\r\n")
+		line = line[i:]
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+func sortedKeys(h textproto.MIMEHeader) []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// part is a node of the MIME tree: either a leaf with a body or a
+// multipart container.
+type part struct {
+	header   textproto.MIMEHeader
+	body     func(io.Writer) error
+	subtype  string
+	children []*part
+}
+
+func rootPart(r *EmailRequest) *part {
+	var bodies []*part
+	if r.TextBody != "" {
+		bodies = append(bodies, textPart("text/plain", r.TextBody))
+	}
+	if r.HTMLBody != "" {
+		bodies = append(bodies, textPart("text/html", r.HTMLBody))
+	}
+	if len(bodies) == 0 {
+		bodies = append(bodies, textPart("text/plain", ""))
+	}
+	root := multipartOf("alternative", bodies)
+
+	if len(r.InlineImages) > 0 {
+		children := []*part{root}
+		for _, img := range r.InlineImages {
+			children = append(children, inlinePart(img))
+		}
+		root = multipartOf("related", children)
+	}
+
+	if len(r.Attachments) > 0 {
+		children := []*part{root}
+		for _, a := range r.Attachments {
+			children = append(children, attachmentPart(a))
+		}
+		root = multipartOf("mixed", children)
+	}
+	return root
+}
+
+// multipartOf wraps children in a multipart of the given subtype, or
+// returns the only child as is.
+func multipartOf(subtype string, children []*part) *part {
+	if len(children) == 1 {
+		return children[0]
+	}
+	return &part{subtype: subtype, children: children}
+}
+
+func textPart(contentType, body string) *part {
+	return &part{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		body: func(w io.Writer) error {
+			qp := quotedprintable.NewWriter(w)
+			if _, err := io.WriteString(qp, body); err != nil {
+				return err
+			}
+			return qp.Close()
+		},
+	}
+}
+
+func attachmentPart(a Attachment) *part {
+	contentType := a.MimeType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &part{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+		},
+		body: base64Body(a.Content),
+	}
+}
+
+func inlinePart(img InlineImage) *part {
+	contentType := img.MimeType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &part{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {"inline"},
+			"Content-Id":                {"<" + img.CID + ">"},
+		},
+		body: base64Body(img.Content),
+	}
+}
+
+// base64Body re-encodes base64 content in lines of 76 characters.
+func base64Body(content string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		raw, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return &ValidationError{Details: []ErrorDetail{{Code: ValidationInvalid, Target: "content", Message: "content is not valid base64"}}}
+		}
+		enc := base64.StdEncoding.EncodeToString(raw)
+		for len(enc) > 76 {
+			if _, err := io.WriteString(w, enc[:76]+"\r\n"); err != nil {
+				return err
+			}
+			enc = enc[76:]
+		}
+		_, err = io.WriteString(w, enc+"\r\n")
+		return err
+	}
+}
+
+// contentType returns the Content-Type of a multipart. multipart/related
+// names the type of its root part, as RFC 2387 requires.
+func (p *part) contentType(boundary string) string {
+	params := map[string]string{"boundary": boundary}
+	if p.subtype == "related" {
+		root := p.children[0]
+		if root.children != nil {
+			params["type"] = "multipart/" + root.subtype
+		} else {
+			params["type"], _, _ = mime.ParseMediaType(root.header.Get("Content-Type"))
+		}
+	}
+	return mime.FormatMediaType("multipart/"+p.subtype, params)
+}
+
+// write renders p as the message root, after the top-level headers.
+func (p *part) write(h *headerWriter, w io.Writer) error {
+	if p.children == nil {
+		if err := h.flush(p.header); err != nil {
+			return err
+		}
+		return p.body(w)
+	}
+	mw := multipart.NewWriter(w)
+	if err := h.flush(textproto.MIMEHeader{"Content-Type": {p.contentType(mw.Boundary())}}); err != nil {
+		return err
+	}
+	return p.writeChildren(mw)
+}
+
+func (p *part) writeChildren(mw *multipart.Writer) error {
+	for _, c := range p.children {
+		if c.children == nil {
+			pw, err := mw.CreatePart(c.header)
+			if err != nil {
+				return err
+			}
+			if err := c.body(pw); err != nil {
+				return err
+			}
+			continue
+		}
+		var buf bytes.Buffer
+		nested := multipart.NewWriter(&buf)
+		if err := c.writeChildren(nested); err != nil {
+			return err
+		}
+		pw, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {c.contentType(nested.Boundary())}})
+		if err != nil {
+			return err
+		}
+		if _, err := buf.WriteTo(pw); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
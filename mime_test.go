@@ -0,0 +1,248 @@
+package zeptomail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mimeLeaf struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// mimeTree describes the structure of a parsed message as its content
+// types, with multipart children in brackets, and collects the leaf parts
+// by content type.
+func mimeTree(t *testing.T, contentType string, body io.Reader, leaves map[string]mimeLeaf) string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return mediaType
+	}
+	mr := multipart.NewReader(body, params["boundary"])
+	var children []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct := p.Header.Get("Content-Type")
+		if mt, _, _ := mime.ParseMediaType(ct); !strings.HasPrefix(mt, "multipart/") {
+			data, err := io.ReadAll(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+				data, err = base64.StdEncoding.DecodeString(strings.ReplaceAll(string(data), "\r\n", ""))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			leaves[mt] = mimeLeaf{p.Header, data}
+		}
+		children = append(children, mimeTree(t, ct, p, leaves))
+	}
+	return mediaType + "[" + strings.Join(children, " ") + "]"
+}
+
+func TestWriteMIME_Structure(t *testing.T) {
+	req := &EmailRequest{
+		From:         EmailAddress{Address: "sender@example.com", Name: "Zoë Sender"},
+		To:           []Recipient{newRecipient("to@example.org", "To")},
+		Cc:           []Recipient{newRecipient("cc@example.org", "")},
+		Bcc:          []Recipient{newRecipient("hidden@example.org", "")},
+		ReplyTo:      []EmailAddress{{Address: "reply@example.com"}},
+		Subject:      "Grüße aus Köln",
+		TextBody:     "Hello = world",
+		HTMLBody:     `<p>Hello</p><img src="cid:logo">`,
+		Attachments:  []Attachment{newAttachment("report.pdf", []byte("%PDF-1.4 report"))},
+		InlineImages: []InlineImage{newInlineImage("logo", "", []byte("\x89PNG\r\n\x1a\n"))},
+		MimeHeaders:  map[string]string{"X-Campaign": "october"},
+	}
+	var buf bytes.Buffer
+	date := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	if err := writeMIME(&buf, req, mimeMessage{messageID: "<id@example.com>", date: date}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(mime.WordDecoder)
+	subject, _ := dec.DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "Grüße aus Köln" {
+		t.Errorf("Subject = %q", subject)
+	}
+	from, err := msg.Header.AddressList("From")
+	if err != nil || from[0].Name != "Zoë Sender" {
+		t.Errorf("From = %v, %v", from, err)
+	}
+	if msg.Header.Get("Bcc") != "" || strings.Contains(buf.String(), "hidden@example.org") {
+		t.Error("Bcc leaked into the message")
+	}
+	for key, want := range map[string]string{
+		"Cc":           "<cc@example.org>",
+		"Reply-To":     "<reply@example.com>",
+		"Message-Id":   "<id@example.com>",
+		"Date":         "Thu, 01 Oct 2026 12:00:00 +0000",
+		"Mime-Version": "1.0",
+		"X-Campaign":   "october",
+	} {
+		if got := msg.Header.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	leaves := map[string]mimeLeaf{}
+	tree := mimeTree(t, msg.Header.Get("Content-Type"), msg.Body, leaves)
+	want := "multipart/mixed[multipart/related[multipart/alternative[text/plain text/html] image/png] application/pdf]"
+	if tree != want {
+		t.Errorf("structure\n%s\nwant\n%s", tree, want)
+	}
+	if got := string(leaves["text/plain"].body); got != "Hello = world" {
+		t.Errorf("text body = %q", got)
+	}
+	if got := leaves["image/png"].header.Get("Content-Id"); got != "<logo>" {
+		t.Errorf("Content-ID = %q", got)
+	}
+	if _, params, _ := mime.ParseMediaType(leaves["application/pdf"].header.Get("Content-Disposition")); params["filename"] != "report.pdf" {
+		t.Errorf("attachment name = %q", params["filename"])
+	}
+	pdf, _ := base64.StdEncoding.DecodeString(req.Attachments[0].Content)
+	if got := leaves["application/pdf"].body; !bytes.Equal(got, pdf) {
+		t.Errorf("attachment content = %q", got)
+	}
+}
+
+func TestWriteMIME_SinglePart(t *testing.T) {
+	req := &EmailRequest{
+		From:     EmailAddress{Address: "sender@example.com"},
+		To:       []Recipient{newRecipient("to@example.org", "")},
+		Subject:  "Plain",
+		TextBody: strings.Repeat("long line ", 20),
+	}
+	var buf bytes.Buffer
+	if err := writeMIME(&buf, req, mimeMessage{messageID: "<id@x>", date: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := msg.Header.Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if te := msg.Header.Get("Content-Transfer-Encoding"); te != "quoted-printable" {
+		t.Errorf("Content-Transfer-Encoding = %q", te)
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 78 {
+			t.Errorf("line longer than 78 characters: %q", line)
+		}
+	}
+}
+
+func TestWriteMIME_FileCacheAttachment(t *testing.T) {
+	req := testEmailRequest()
+	req.Attachments = []Attachment{{FileCacheKey: "key"}}
+	err := writeMIME(io.Discard, req, mimeMessage{})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Details[0].Target != "attachments[0].file_cache_key" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestWriteMIME_InvalidHeaderName(t *testing.T) {
+	req := testEmailRequest()
+	req.MimeHeaders = map[string]string{"X-A\r\nBcc": "victim@example.com"}
+	eml, err := req.ToEML()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Details[0].Target != "mime_headers" {
+		t.Errorf("err = %v", err)
+	}
+	if eml != nil {
+		t.Errorf("rendered %q", eml)
+	}
+}
+
+func TestEmailRequest_ToEML(t *testing.T) {
+	req := &EmailRequest{
+		From:    EmailAddress{Address: "sender@example.com", Name: "Ünïcødé Sender"},
+		To:      []Recipient{newRecipient("a@example.org", "A"), {EmailAddress: EmailAddress{Address: "b@example.org", Name: "B"}, MergeInfo: map[string]string{"name": "Bea", "code": "B-2"}}},
+		Subject: "Hi {{name}}, your code is {{ code }} and this subject is long enough to need folding: ✓✓✓✓✓✓✓✓",
+		// {{unknown}} has no value and is left alone.
+		TextBody:  "Dear {{name}}, {{unknown}}",
+		MergeInfo: map[string]string{"name": "friend", "code": "X-0"},
+	}
+	date := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	eml, err := req.ToEML(ForRecipient("B@example.org"), WithMessageID("<fixed@example.com>"), WithDate(date))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(eml), "\r\n") {
+		if len(line) > 78 {
+			t.Errorf("line longer than 78 characters: %q", line)
+		}
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(eml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || !strings.HasPrefix(subject, "Hi Bea, your code is B-2 and") || !strings.HasSuffix(subject, "✓✓✓✓✓✓✓✓") {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	if to := msg.Header.Get("To"); to != `"B" <b@example.org>` {
+		t.Errorf("To = %q", to)
+	}
+	if !strings.HasPrefix(msg.Header.Get("From"), "=?utf-8?") {
+		t.Errorf("From name not encoded: %q", msg.Header.Get("From"))
+	}
+	if msg.Header.Get("Message-Id") != "<fixed@example.com>" || msg.Header.Get("Date") != "Fri, 16 Oct 2026 09:30:00 +0000" {
+		t.Errorf("Message-Id = %q, Date = %q", msg.Header.Get("Message-Id"), msg.Header.Get("Date"))
+	}
+	body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if string(body) != "Dear Bea, {{unknown}}" {
+		t.Errorf("body = %q", body)
+	}
+	if req.Subject[:8] != "Hi {{nam" || len(req.To) != 2 {
+		t.Error("ToEML modified the request")
+	}
+
+	// Without a recipient the request-level merge info applies.
+	eml, err = req.ToEML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, _ = mail.ReadMessage(bytes.NewReader(eml))
+	body, _ = io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if string(body) != "Dear friend, {{unknown}}" || !strings.Contains(msg.Header.Get("To"), "a@example.org") {
+		t.Errorf("body = %q, To = %q", body, msg.Header.Get("To"))
+	}
+	if msg.Header.Get("Message-Id") == "" || msg.Header.Get("Date") == "" {
+		t.Error("Message-ID and Date are not generated")
+	}
+
+	var verr *ValidationError
+	if _, err := req.ToEML(ForRecipient("nobody@example.org")); !errors.As(err, &verr) {
+		t.Errorf("unknown recipient: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package zeptomail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// maxMIMEDepth bounds multipart nesting in ParseMIME.
+const maxMIMEDepth = 16
+
+// MaxMIMEBytes caps the size of a message read by ParseMIME and of the body
+// read by ParseMailMessage. It leaves room for MaxAttachmentBytes of content
+// in base64, which adds a third.
+const MaxMIMEBytes = 24 << 20
+
+// ErrMIMETooLarge is returned by ParseMIME and ParseMailMessage when a
+// message exceeds MaxMIMEBytes.
+var ErrMIMETooLarge = errors.New("zeptomail: message too large")
+
+// ParseMIME reads an RFC 5322 message, e.g. an .eml file, and converts it
+// into an EmailRequest. See ParseMailMessage.
+func ParseMIME(r io.Reader) (*EmailRequest, error) {
+	lr := &maxReader{r: r, n: MaxMIMEBytes}
+	msg, err := mail.ReadMessage(lr)
+	if lr.exceeded() {
+		return nil, ErrMIMETooLarge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("zeptomail: parsing message: %w", err)
+	}
+	return ParseMailMessage(msg)
+}
+
+// ParseMailMessage converts a parsed message into an EmailRequest. It takes
+// From, To, Cc, Bcc and Reply-To, the decoded subject and any X- headers,
+// which become MimeHeaders. Multipart bodies are walked to find the text and
+// HTML bodies, attachments and inline images; parts in ISO-8859-1 are
+// converted to UTF-8. Inline images are image parts with a Content-ID that
+// are not marked as attachments; other parts with a Content-ID are treated
+// like any other part.
+//
+// The request is not validated; call Validate or rely on EmailClient to do
+// so before sending.
+func ParseMailMessage(msg *mail.Message) (*EmailRequest, error) {
+	req := &EmailRequest{}
+	h := msg.Header
+
+	if v := h.Get("From"); v != "" {
+		from, err := mail.ParseAddress(v)
+		if err != nil {
+			return nil, fmt.Errorf("zeptomail: parsing From: %w", err)
+		}
+		req.From = EmailAddress{Address: from.Address, Name: from.Name}
+	}
+	var err error
+	if req.To, err = parseRecipients(h, "To"); err != nil {
+		return nil, err
+	}
+	if req.Cc, err = parseRecipients(h, "Cc"); err != nil {
+		return nil, err
+	}
+	if req.Bcc, err = parseRecipients(h, "Bcc"); err != nil {
+		return nil, err
+	}
+	replyTo, err := parseRecipients(h, "Reply-To")
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range replyTo {
+		req.ReplyTo = append(req.ReplyTo, r.EmailAddress)
+	}
+	req.Subject = decodeHeader(h.Get("Subject"))
+
+	for name, values := range h {
+		if len(name) > 2 && strings.EqualFold(name[:2], "X-") && len(values) > 0 {
+			if req.MimeHeaders == nil {
+				req.MimeHeaders = make(map[string]string)
+			}
+			req.MimeHeaders[name] = decodeHeader(values[0])
+		}
+	}
+
+	header := textproto.MIMEHeader(h)
+	body := &maxReader{r: msg.Body, n: MaxMIMEBytes}
+	if err := req.addPart(header, body, 0); err != nil {
+		// Parsers may report a truncated read in their own words.
+		if body.exceeded() {
+			return nil, ErrMIMETooLarge
+		}
+		return nil, err
+	}
+	return req, nil
+}
+
+// maxReader reads from r, failing with ErrMIMETooLarge once more than n
+// bytes have been read.
+type maxReader struct {
+	r io.Reader
+	n int64
+}
+
+func (m *maxReader) Read(p []byte) (int, error) {
+	if m.n < 0 {
+		return 0, ErrMIMETooLarge
+	}
+	if int64(len(p)) > m.n+1 {
+		p = p[:m.n+1]
+	}
+	n, err := m.r.Read(p)
+	m.n -= int64(n)
+	if m.n < 0 {
+		return n, ErrMIMETooLarge
+	}
+	return n, err
+}
+
+func (m *maxReader) exceeded() bool {
+	return m.n < 0
+}
+
+func parseRecipients(h mail.Header, field string) ([]Recipient, error) {
+	if h.Get(field) == "" {
+		return nil, nil
+	}
+	list, err := h.AddressList(field)
+	if err != nil {
+		return nil, fmt.Errorf("zeptomail: parsing %s: %w", field, err)
+	}
+	rs := make([]Recipient, len(list))
+	for i, a := range list {
+		rs[i] = newRecipient(a.Address, a.Name)
+	}
+	return rs, nil
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// decodeHeader decodes encoded-words, keeping the raw value if that fails.
+func decodeHeader(v string) string {
+	if s, err := wordDecoder.DecodeHeader(v); err == nil {
+		return s
+	}
+	return v
+}
+
+// addPart adds a MIME part, descending into multiparts.
+func (req *EmailRequest) addPart(h textproto.MIMEHeader, body io.Reader, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMIMEDepth {
+			return errors.New("zeptomail: parsing message: multipart nested too deeply")
+		}
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("zeptomail: parsing message: %w", err)
+			}
+			if err := req.addPart(p.Header, p, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := io.ReadAll(decodeTransfer(h.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("zeptomail: parsing message: %w", err)
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+	filename := decodeHeader(dparams["filename"])
+	if filename == "" {
+		filename = decodeHeader(params["name"])
+	}
+	cid := strings.Trim(h.Get("Content-Id"), "<> ")
+
+	switch {
+	case disposition != "attachment" && filename == "" && mediaType == "text/plain" && req.TextBody == "":
+		req.TextBody = toUTF8(content, params["charset"])
+	case disposition != "attachment" && filename == "" && mediaType == "text/html" && req.HTMLBody == "":
+		req.HTMLBody = toUTF8(content, params["charset"])
+	case disposition != "attachment" && cid != "" && isImageType(mediaType):
+		req.InlineImages = append(req.InlineImages, InlineImage{
+			CID:      cid,
+			Content:  base64.StdEncoding.EncodeToString(content),
+			MimeType: mediaType,
+		})
+	default:
+		if filename == "" {
+			filename = defaultFilename(mediaType)
+		}
+		req.Attachments = append(req.Attachments, Attachment{
+			Content:  base64.StdEncoding.EncodeToString(content),
+			MimeType: mediaType,
+			Name:     filename,
+		})
+	}
+	return nil
+}
+
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &base64Cleaner{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// base64Cleaner drops the line breaks and other whitespace that
+// base64.NewDecoder does not skip by itself.
+type base64Cleaner struct {
+	r io.Reader
+}
+
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	out := p[:0]
+	for _, b := range p[:n] {
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			out = append(out, b)
+		}
+	}
+	return len(out), err
+}
+
+// charsetReader supports the charsets of the standard library decoders plus
+// ISO-8859-1.
+func charsetReader(charset string, r io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii", "ascii":
+		return r, nil
+	case "iso-8859-1", "latin1":
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(latin1ToUTF8(b)), nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", charset)
+}
+
+func toUTF8(b []byte, charset string) string {
+	r, err := charsetReader(charset, bytes.NewReader(b))
+	if charset == "" || err != nil {
+		return string(b)
+	}
+	s, _ := io.ReadAll(r)
+	return string(s)
+}
+
+func latin1ToUTF8(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func defaultFilename(mediaType string) string {
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return "attachment" + exts[0]
+	}
+	return "attachment"
+}
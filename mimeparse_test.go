@@ -0,0 +1,196 @@
+package zeptomail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const legacyMessage = "From: =?iso-8859-1?q?Ren=E9?= <rene@example.com>\r\n" +
+	"To: Alice <alice@example.org>, bob@example.org\r\n" +
+	"Cc: \"Carol C.\" <carol@example.org>\r\n" +
+	"Reply-To: support@example.com\r\n" +
+	"Subject: =?utf-8?b?UmFwcG9ydCBtZW5zdWVs?= =?utf-8?q?_=E2=80=94_octobre?=\r\n" +
+	"X-Mailer: LegacyMailer 2.1\r\n" +
+	"X-Report-Id: =?utf-8?q?r=C3=A9f-42?=\r\n" +
+	"Received: from somewhere\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"This is a multi-part message in MIME format.\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/related; boundary=rel\r\n" +
+	"\r\n" +
+	"--rel\r\n" +
+	"Content-Type: multipart/alternative; boundary=alt\r\n" +
+	"\r\n" +
+	"--alt\r\n" +
+	"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Voil=E0 le rapport, tr=E8s long =\r\n" +
+	"sur deux lignes.\r\n" +
+	"--alt\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"PHA+Vm9pbMOgPC9wPjxpbWcgc3JjPSJjaWQ6\r\n" +
+	"Y2hhcnQiPg==\r\n" +
+	"--alt--\r\n" +
+	"--rel\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"Content-ID: <chart>\r\n" +
+	"\r\n" +
+	"iVBORw0KGgo=\r\n" +
+	"--rel--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/csv; name=\"ignored.csv\"\r\n" +
+	"Content-Disposition: attachment; filename*=utf-8''donn%C3%A9es.csv\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"YSxiCjEsMgo=\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"Content-Disposition: attachment; filename=notes.txt\r\n" +
+	"\r\n" +
+	"plain attachment\r\n" +
+	"--outer--\r\n"
+
+func TestParseMIME(t *testing.T) {
+	req, err := ParseMIME(strings.NewReader(legacyMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.From != (EmailAddress{Address: "rene@example.com", Name: "René"}) {
+		t.Errorf("From = %+v", req.From)
+	}
+	wantTo := []Recipient{newRecipient("alice@example.org", "Alice"), newRecipient("bob@example.org", "")}
+	if !reflect.DeepEqual(req.To, wantTo) {
+		t.Errorf("To = %+v", req.To)
+	}
+	if len(req.Cc) != 1 || req.Cc[0].Name != "Carol C." || len(req.ReplyTo) != 1 || req.ReplyTo[0].Address != "support@example.com" {
+		t.Errorf("Cc = %+v, ReplyTo = %+v", req.Cc, req.ReplyTo)
+	}
+	if req.Subject != "Rapport mensuel — octobre" {
+		t.Errorf("Subject = %q", req.Subject)
+	}
+	wantHeaders := map[string]string{"X-Mailer": "LegacyMailer 2.1", "X-Report-Id": "réf-42"}
+	if !reflect.DeepEqual(req.MimeHeaders, wantHeaders) {
+		t.Errorf("MimeHeaders = %v", req.MimeHeaders)
+	}
+	if req.TextBody != "Voilà le rapport, très long sur deux lignes." {
+		t.Errorf("TextBody = %q", req.TextBody)
+	}
+	if req.HTMLBody != `<p>Voilà</p><img src="cid:chart">` {
+		t.Errorf("HTMLBody = %q", req.HTMLBody)
+	}
+	if len(req.InlineImages) != 1 || req.InlineImages[0].CID != "chart" || req.InlineImages[0].MimeType != "image/png" || req.InlineImages[0].Content != "iVBORw0KGgo=" {
+		t.Errorf("InlineImages = %+v", req.InlineImages)
+	}
+	if len(req.Attachments) != 2 {
+		t.Fatalf("got %d attachments, want 2", len(req.Attachments))
+	}
+	csv := req.Attachments[0]
+	if csv.Name != "données.csv" || csv.MimeType != "text/csv" || csv.Content != base64.StdEncoding.EncodeToString([]byte("a,b\n1,2\n")) {
+		t.Errorf("csv attachment = %+v", csv)
+	}
+	if notes := req.Attachments[1]; notes.Name != "notes.txt" || notes.Content != base64.StdEncoding.EncodeToString([]byte("plain attachment")) {
+		t.Errorf("notes attachment = %+v", notes)
+	}
+	if err := req.Validate(); err != nil {
+		t.Errorf("parsed request does not validate: %v", err)
+	}
+}
+
+func TestParseMIME_RoundTrip(t *testing.T) {
+	orig := &EmailRequest{
+		From:         EmailAddress{Address: "sender@example.com", Name: "Zoë"},
+		To:           []Recipient{newRecipient("to@example.org", "Tö")},
+		Cc:           []Recipient{newRecipient("cc@example.org", "")},
+		ReplyTo:      []EmailAddress{{Address: "reply@example.com", Name: "Reply"}},
+		Subject:      "Round trip ✓ with a subject long enough to be folded over two lines",
+		TextBody:     "Text body with a very long line that quoted-printable has to wrap because it is longer than 76 characters.",
+		HTMLBody:     `<p>HTML</p><img src="cid:logo">`,
+		Attachments:  []Attachment{newAttachment("report.pdf", []byte("%PDF-1.4 report"))},
+		InlineImages: []InlineImage{newInlineImage("logo", "", []byte("\x89PNG\r\n\x1a\n"))},
+		MimeHeaders:  map[string]string{"X-Campaign": "october"},
+	}
+	eml, err := orig.ToEML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseMIME(bytes.NewReader(eml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, orig) {
+		t.Errorf("round trip mismatch\n got %+v\nwant %+v", got, orig)
+	}
+}
+
+func TestParseMIME_Malformed(t *testing.T) {
+	for _, msg := range []string{
+		"",
+		"From: not an address\r\n\r\nbody",
+		"From: a@example.com\r\nContent-Type: multipart/mixed; boundary=b\r\n\r\n--b\r\nContent-Type: text/plain\r\n\r\nunterminated",
+		"From: a@example.com\r\nContent-Type: multipart/mixed; boundary=b\r\n\r\n" + strings.Repeat("--b\r\nContent-Type: multipart/mixed; boundary=b\r\n\r\n", 20),
+	} {
+		if _, err := ParseMIME(strings.NewReader(msg)); err == nil {
+			t.Errorf("ParseMIME(%q) succeeded", msg)
+		}
+	}
+}
+
+func TestParseMIME_ContentIDOnNonImages(t *testing.T) {
+	msg := "From: a@example.com\r\n" +
+		"Content-Type: multipart/related; boundary=rel\r\n" +
+		"\r\n" +
+		"--rel\r\n" +
+		"Content-Type: text/html\r\n" +
+		"Content-ID: <body@example.com>\r\n" +
+		"\r\n" +
+		"<img src=\"cid:logo\"><a href=\"cid:terms\">terms</a>\r\n" +
+		"--rel\r\n" +
+		"Content-Type: image/png\r\n" +
+		"Content-Disposition: inline\r\n" +
+		"Content-ID: <logo>\r\n" +
+		"\r\n" +
+		"png\r\n" +
+		"--rel\r\n" +
+		"Content-Type: application/pdf\r\n" +
+		"Content-ID: <terms>\r\n" +
+		"\r\n" +
+		"pdf\r\n" +
+		"--rel--\r\n"
+	req, err := ParseMIME(strings.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(req.HTMLBody, "cid:logo") {
+		t.Errorf("HTMLBody = %q, want the HTML part despite its Content-ID", req.HTMLBody)
+	}
+	if len(req.InlineImages) != 1 || req.InlineImages[0].CID != "logo" {
+		t.Errorf("InlineImages = %+v, want only logo", req.InlineImages)
+	}
+	if len(req.Attachments) != 1 || req.Attachments[0].MimeType != "application/pdf" {
+		t.Errorf("Attachments = %+v, want the PDF", req.Attachments)
+	}
+}
+
+func TestParseMIME_TooLarge(t *testing.T) {
+	big := strings.Repeat("x", MaxMIMEBytes)
+	for name, msg := range map[string]string{
+		"header": "From: a@example.com\r\nX-Big: " + big + "\r\n\r\nbody",
+		"body":   "From: a@example.com\r\nContent-Type: text/plain\r\n\r\n" + big,
+		"part":   "From: a@example.com\r\nContent-Type: multipart/mixed; boundary=b\r\n\r\n--b\r\nContent-Type: text/plain\r\n\r\n" + big + "\r\n--b--\r\n",
+	} {
+		if _, err := ParseMIME(strings.NewReader(msg)); !errors.Is(err, ErrMIMETooLarge) {
+			t.Errorf("%s: err = %v, want ErrMIMETooLarge", name, err)
+		}
+	}
+}
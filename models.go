@@ -1,5 +1,7 @@
 package zeptomail
 
+import "io"
+
 type EmailAddress struct {
 	Address string `json:"address"`
 	Name    string `json:"name,omitempty"`
@@ -27,6 +29,17 @@ type InlineImage struct {
 	FileCacheKey string `json:"file_cache_key,omitempty"`
 }
 
+// FileUploadRequest is the payload middleware sees for FileCacheUpload and
+// FileCacheUploadReader. Content is set by the former, Reader, Size and
+// ContentType by the latter.
+type FileUploadRequest struct {
+	Filename    string
+	Content     []byte
+	Reader      io.Reader
+	Size        int64
+	ContentType string
+}
+
 // ListTemplatesParams controls pagination for ListTemplates.
 type ListTemplatesParams struct {
 	Offset int
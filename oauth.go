@@ -0,0 +1,125 @@
+package zeptomail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go/internal/transport"
+)
+
+// DefaultAccountsURL is the Zoho accounts server of the US data center. Use
+// Region.AccountsURL for the others.
+const DefaultAccountsURL = "https://accounts.zoho.com"
+
+// Token is an OAuth access token with its expiry.
+type Token = transport.Token
+
+// TokenSource supplies OAuth access tokens to a TemplatesClient. The client
+// caches each token until shortly before it expires and asks for a new one
+// once if the API rejects the cached token with a 401 INVALID_OAUTHTOKEN
+// error; other 401s are returned without a second attempt.
+type TokenSource = transport.TokenSource
+
+// NewTemplatesClientWithTokenSource returns a templates client that takes
+// its OAuth tokens from src, e.g. a *ZohoTokenSource, so that they are
+// renewed without restarting the service. With WithRegion, a ZohoTokenSource
+// that sets neither AccountsURL nor Region refreshes against the accounts
+// server of that region.
+func NewTemplatesClientWithTokenSource(src TokenSource, opts ...Option) *TemplatesClient {
+	cfg := newClientConfig(opts)
+	if z, ok := src.(*ZohoTokenSource); ok && z.AccountsURL == "" && z.Region == "" && cfg.region != "" {
+		zs := *z
+		zs.Region = cfg.region
+		src = &zs
+	}
+	topts := append(cfg.transportOptions(), transport.WithTokenSource(src))
+	return &TemplatesClient{
+		httpClient: transport.NewTemplatesClient("", cfg.baseURL, cfg.httpClient, topts...),
+	}
+}
+
+// ZohoTokenSource obtains access tokens from the Zoho accounts server using
+// a long-lived refresh token. Every call to Token performs a refresh; the
+// TemplatesClient caches the result.
+type ZohoTokenSource struct {
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+	// AccountsURL is the accounts server of your data center. It defaults
+	// to the accounts server of Region and can point at a local stub in
+	// tests.
+	AccountsURL string
+	// Region selects the accounts server when AccountsURL is empty. It
+	// defaults to RegionUS.
+	Region Region
+	// HTTPClient defaults to a client with a 30 s timeout.
+	HTTPClient *http.Client
+}
+
+type zohoTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	Error       string `json:"error"`
+}
+
+// Token exchanges the refresh token for a new access token.
+func (z *ZohoTokenSource) Token(ctx context.Context) (*Token, error) {
+	accountsURL := z.AccountsURL
+	if accountsURL == "" {
+		accountsURL = z.Region.AccountsURL()
+	}
+	if accountsURL == "" {
+		accountsURL = DefaultAccountsURL
+	}
+	httpClient := z.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient()
+	}
+
+	form := url.Values{
+		"refresh_token": {z.RefreshToken},
+		"client_id":     {z.ClientID},
+		"client_secret": {z.ClientSecret},
+		"grant_type":    {"refresh_token"},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(accountsURL, "/")+"/oauth/v2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("zeptomail: creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("zeptomail: refreshing OAuth token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("zeptomail: reading token response: %w", err)
+	}
+
+	// Zoho reports errors such as invalid_code with a 200 status, so the
+	// error field is checked regardless of the status code.
+	var tr zohoTokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil || resp.StatusCode != http.StatusOK || tr.Error != "" || tr.AccessToken == "" {
+		reason := tr.Error
+		if reason == "" {
+			reason = http.StatusText(resp.StatusCode)
+		}
+		return nil, fmt.Errorf("zeptomail: refreshing OAuth token: HTTP %d: %s", resp.StatusCode, reason)
+	}
+
+	tok := &Token{AccessToken: tr.AccessToken}
+	if tr.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
@@ -0,0 +1,95 @@
+package zeptomail
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func zohoAccountsStub(t *testing.T, refreshes *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth/v2/token" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("client_id") != "cid" ||
+			r.Form.Get("client_secret") != "secret" || r.Form.Get("refresh_token") != "refresh" {
+			w.Write([]byte(`{"error":"invalid_code"}`))
+			return
+		}
+		n := atomic.AddInt32(refreshes, 1)
+		w.Write([]byte(`{"access_token":"access-` + string(rune('0'+n)) + `","expires_in":3600,"token_type":"Bearer"}`))
+	}))
+}
+
+func TestZohoTokenSource_Token(t *testing.T) {
+	var refreshes int32
+	accounts := zohoAccountsStub(t, &refreshes)
+	defer accounts.Close()
+
+	src := &ZohoTokenSource{ClientID: "cid", ClientSecret: "secret", RefreshToken: "refresh", AccountsURL: accounts.URL}
+	tok, err := src.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "access-1" {
+		t.Errorf("AccessToken = %q", tok.AccessToken)
+	}
+	if until := time.Until(tok.Expiry); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("Expiry in %v, want about an hour", until)
+	}
+}
+
+func TestZohoTokenSource_Error(t *testing.T) {
+	var refreshes int32
+	accounts := zohoAccountsStub(t, &refreshes)
+	defer accounts.Close()
+
+	src := &ZohoTokenSource{ClientID: "cid", ClientSecret: "wrong", RefreshToken: "refresh", AccountsURL: accounts.URL}
+	_, err := src.Token(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid_code") {
+		t.Errorf("err = %v, want invalid_code", err)
+	}
+}
+
+func TestTemplatesClient_RefreshesRejectedToken(t *testing.T) {
+	var refreshes int32
+	accounts := zohoAccountsStub(t, &refreshes)
+	defer accounts.Close()
+
+	var seen []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		seen = append(seen, auth)
+		if auth == "Zoho-oauthtoken access-1" {
+			w.WriteHeader(401)
+			w.Write([]byte(`{"error":{"code":"INVALID_OAUTHTOKEN","message":"Invalid OAuth token"}}`))
+			return
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(`{"message":"OK"}`))
+	}))
+	defer api.Close()
+
+	src := &ZohoTokenSource{ClientID: "cid", ClientSecret: "secret", RefreshToken: "refresh", AccountsURL: accounts.URL}
+	client := NewTemplatesClientWithTokenSource(src, WithBaseURL(api.URL))
+	if err := client.DeleteTemplate(context.Background(), "agent", "key"); err != nil {
+		t.Fatal(err)
+	}
+	if refreshes != 2 || len(seen) != 2 || seen[1] != "Zoho-oauthtoken access-2" {
+		t.Errorf("refreshes = %d, seen = %v", refreshes, seen)
+	}
+
+	// The refreshed token is cached for later calls.
+	if err := client.DeleteTemplate(context.Background(), "agent", "key"); err != nil {
+		t.Fatal(err)
+	}
+	if refreshes != 2 {
+		t.Errorf("refreshes = %d, want the token to be cached", refreshes)
+	}
+}
@@ -1,13 +1,66 @@
 package zeptomail
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go/internal/transport"
+)
 
 // Option tweaks client behaviour. Pass to NewEmailClient or NewTemplatesClient.
 type Option func(*clientConfig)
 
 type clientConfig struct {
-	httpClient *http.Client
-	baseURL    string
+	httpClient  *http.Client
+	baseURL     string
+	region      Region
+	retryPolicy *RetryPolicy
+	rateLimiter *RateLimiter
+	middleware  []Middleware
+	logger      Middleware
+	tracer      Middleware
+	metrics     Middleware
+
+	skipValidation bool
+}
+
+func newClientConfig(opts []Option) *clientConfig {
+	cfg := &clientConfig{
+		httpClient: defaultHTTPClient(),
+		baseURL:    baseURL,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+func (cfg *clientConfig) transportOptions() []transport.Option {
+	var opts []transport.Option
+	if cfg.retryPolicy != nil {
+		opts = append(opts, transport.WithRetryPolicy(*cfg.retryPolicy))
+	}
+	if cfg.rateLimiter != nil {
+		opts = append(opts, transport.WithRateLimiter(cfg.rateLimiter))
+	}
+	opts = append(opts, transport.WithMiddleware(cfg.allMiddleware()...))
+	return opts
+}
+
+// allMiddleware returns the middleware chain, the built-in observers
+// outermost so that they see the final outcome of the user's middleware.
+func (cfg *clientConfig) allMiddleware() []Middleware {
+	mw := []Middleware{responseMetaMiddleware}
+	if cfg.tracer != nil {
+		mw = append(mw, cfg.tracer)
+	}
+	if cfg.metrics != nil {
+		mw = append(mw, cfg.metrics)
+	}
+	if cfg.logger != nil {
+		mw = append(mw, cfg.logger)
+	}
+	return append(mw, cfg.middleware...)
 }
 
 // WithHTTPClient replaces the default http.Client (which has a 30 s timeout).
@@ -23,3 +76,81 @@ func WithBaseURL(url string) Option {
 		cfg.baseURL = url
 	}
 }
+
+// WithValidation controls whether the send methods of EmailClient validate
+// requests locally before calling the API. It is enabled by default.
+func WithValidation(enabled bool) Option {
+	return func(cfg *clientConfig) {
+		cfg.skipValidation = !enabled
+	}
+}
+
+// RetryPolicy controls automatic retries of failed requests: how many
+// attempts are made, how long to back off between them and which HTTP
+// statuses are worth retrying. Connection errors are always retried.
+type RetryPolicy = transport.RetryPolicy
+
+// DefaultRetryPolicy returns a policy of 3 attempts with exponential backoff
+// starting at 500 ms, capped at 10 s, retrying 429, 500, 502, 503 and 504.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: 500 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// WithRetryPolicy enables automatic retries. Retry-After headers are honoured
+// as long as they do not exceed p.MaxBackoff, and retrying stops as soon as
+// the request context is done. Clients make a single attempt by default.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(cfg *clientConfig) {
+		cfg.retryPolicy = &p
+	}
+}
+
+// RateLimiter is a client-side token bucket that paces requests to the API.
+// Share one limiter between every EmailClient and TemplatesClient that sends
+// on behalf of the same account to keep their combined throughput in check.
+type RateLimiter = transport.RateLimiter
+
+// NewRateLimiter returns a limiter allowing requestsPerSecond on average with
+// bursts of up to burst requests.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	return transport.NewRateLimiter(requestsPerSecond, burst)
+}
+
+// WithRateLimit gives the client its own limiter. Requests block until a
+// token is available or the request context is done.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(cfg *clientConfig) {
+		cfg.rateLimiter = NewRateLimiter(requestsPerSecond, burst)
+	}
+}
+
+// WithRateLimiter attaches a limiter that may be shared with other clients.
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(cfg *clientConfig) {
+		cfg.rateLimiter = l
+	}
+}
+
+// Call describes one logical operation (Operation is the method name, such as
+// "SendEmail" or "CreateTemplate") together with its typed request payload.
+type Call = transport.Call
+
+// Handler performs a Call. On success it returns the operation's typed
+// result (for example *SuccessResponse); API failures are *APIError values.
+type Handler = transport.Handler
+
+// Middleware wraps a Handler, e.g. for logging, tracing or payload rewriting.
+type Middleware = transport.Middleware
+
+// WithMiddleware adds middleware around every client operation. Middleware
+// run in the order given, the first one being the outermost.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(cfg *clientConfig) {
+		cfg.middleware = append(cfg.middleware, mw...)
+	}
+}
@@ -0,0 +1,22 @@
+module github.com/navnitms/zeptomail-sdk-go/otel
+
+go 1.20
+
+require (
+	github.com/navnitms/zeptomail-sdk-go v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+)
+
+// Until the SDK publishes a tag that includes Tracer, the adapter is built
+// against the SDK in the parent directory. Replace this with a require of
+// that tag when it is released.
+replace github.com/navnitms/zeptomail-sdk-go => ../
@@ -0,0 +1,84 @@
+// Package zeptomailotel adapts OpenTelemetry tracing to the zeptomail
+// SDK's Tracer interface. It lives in its own module so that the SDK does
+// not depend on OpenTelemetry:
+//
+//	client := zeptomail.NewEmailClient(apiKey,
+//		zeptomail.WithTracer(zeptomailotel.NewTracer(nil)),
+//	)
+package zeptomailotel
+
+import (
+	"context"
+	"fmt"
+
+	zeptomail "github.com/navnitms/zeptomail-sdk-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the spans created.
+const ScopeName = "github.com/navnitms/zeptomail-sdk-go/otel"
+
+type tracer struct {
+	t trace.Tracer
+}
+
+// NewTracer returns a zeptomail.Tracer creating client spans with tp. A
+// nil tp uses the global TracerProvider.
+func NewTracer(tp trace.TracerProvider) zeptomail.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tracer{tp.Tracer(ScopeName)}
+}
+
+func (t tracer) Start(ctx context.Context, name string) (context.Context, zeptomail.Span) {
+	ctx, s := t.t.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, span{s}
+}
+
+type span struct {
+	s trace.Span
+}
+
+func (s span) SetAttributes(attrs ...zeptomail.Attribute) {
+	kvs := make([]attribute.KeyValue, len(attrs))
+	for i, a := range attrs {
+		kvs[i] = keyValue(a)
+	}
+	s.s.SetAttributes(kvs...)
+}
+
+func (s span) RecordError(err error) {
+	s.s.RecordError(err)
+	s.s.SetStatus(codes.Error, err.Error())
+}
+
+// TraceParent formats the span context as a W3C traceparent header value.
+func (s span) TraceParent() string {
+	sc := s.s.SpanContext()
+	if !sc.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID(), sc.SpanID(), sc.TraceFlags())
+}
+
+func (s span) End() {
+	s.s.End()
+}
+
+func keyValue(a zeptomail.Attribute) attribute.KeyValue {
+	switch v := a.Value.(type) {
+	case string:
+		return attribute.String(a.Key, v)
+	case int:
+		return attribute.Int(a.Key, v)
+	case int64:
+		return attribute.Int64(a.Key, v)
+	case bool:
+		return attribute.Bool(a.Key, v)
+	}
+	return attribute.String(a.Key, fmt.Sprint(a.Value))
+}
@@ -0,0 +1,104 @@
+package zeptomailotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeptomail "github.com/navnitms/zeptomail-sdk-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func testRequest() *zeptomail.EmailRequest {
+	return &zeptomail.EmailRequest{
+		From:     zeptomail.EmailAddress{Address: "a@b.com"},
+		To:       []zeptomail.Recipient{{EmailAddress: zeptomail.EmailAddress{Address: "c@d.com"}}},
+		Subject:  "hi",
+		TextBody: "hello",
+	}
+}
+
+func TestNewTracer(t *testing.T) {
+	var traceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{"request_id":"req-1","message":"OK"}`))
+	}))
+	defer ts.Close()
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	client := zeptomail.NewEmailClient("test-api-key",
+		zeptomail.WithBaseURL(ts.URL),
+		zeptomail.WithTracer(NewTracer(tp)),
+	)
+	if _, err := client.SendEmail(context.Background(), testRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != "zeptomail.SendEmail" || s.SpanKind() != trace.SpanKindClient {
+		t.Errorf("span %q of kind %v", s.Name(), s.SpanKind())
+	}
+	sc := s.SpanContext()
+	if want := "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-01"; traceparent != want {
+		t.Errorf("traceparent = %q, want %q", traceparent, want)
+	}
+	attrs := attribute.NewSet(s.Attributes()...)
+	if v, _ := attrs.Value(zeptomail.AttrRequestID); v.AsString() != "req-1" {
+		t.Errorf("request_id = %v", v.Emit())
+	}
+	if v, _ := attrs.Value(zeptomail.AttrHTTPStatus); v.AsInt64() != 200 {
+		t.Errorf("status = %v", v.Emit())
+	}
+	if v, _ := attrs.Value(zeptomail.AttrRecipients); v.AsInt64() != 1 {
+		t.Errorf("recipients = %v", v.Emit())
+	}
+}
+
+func TestNewTracer_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":"TM_3201","message":"Mandatory field missing","request_id":"req-2"}}`))
+	}))
+	defer ts.Close()
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	client := zeptomail.NewEmailClient("test-api-key",
+		zeptomail.WithBaseURL(ts.URL),
+		zeptomail.WithTracer(NewTracer(tp)),
+	)
+	if _, err := client.SendEmail(context.Background(), testRequest()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	s := rec.Ended()[0]
+	if s.Status().Code != codes.Error {
+		t.Errorf("status = %v", s.Status())
+	}
+	if len(s.Events()) != 1 || s.Events()[0].Name != "exception" {
+		t.Errorf("events = %v", s.Events())
+	}
+	attrs := attribute.NewSet(s.Attributes()...)
+	if v, _ := attrs.Value(zeptomail.AttrErrorCode); v.AsString() != "TM_3201" {
+		t.Errorf("error_code = %v", v.Emit())
+	}
+}
+
+func TestTraceParent_Invalid(t *testing.T) {
+	_, s := NewTracer(noop.NewTracerProvider()).Start(context.Background(), "op")
+	if tp := s.TraceParent(); tp != "" {
+		t.Errorf("TraceParent() = %q, want empty", tp)
+	}
+}
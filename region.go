@@ -0,0 +1,121 @@
+package zeptomail
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Region identifies a Zoho data center. Accounts live in exactly one data
+// center and must use its API and accounts hosts.
+type Region string
+
+// Data centers served by ZeptoMail.
+const (
+	RegionUS Region = "us"
+	RegionEU Region = "eu"
+	RegionIN Region = "in"
+	RegionAU Region = "au"
+	RegionJP Region = "jp"
+	RegionCA Region = "ca"
+	RegionSA Region = "sa"
+	RegionCN Region = "cn"
+)
+
+type regionHosts struct {
+	api      string
+	accounts string
+	smtp     string
+	// domains are the registrable domains of the data center, used to infer
+	// the region from a host name.
+	domains []string
+}
+
+var regions = map[Region]regionHosts{
+	RegionUS: {"api.zeptomail.com", "accounts.zoho.com", "smtp.zeptomail.com", []string{"zeptomail.com", "zoho.com", "zohoapis.com"}},
+	RegionEU: {"api.zeptomail.eu", "accounts.zoho.eu", "smtp.zeptomail.eu", []string{"zeptomail.eu", "zoho.eu", "zohoapis.eu"}},
+	RegionIN: {"api.zeptomail.in", "accounts.zoho.in", "smtp.zeptomail.in", []string{"zeptomail.in", "zoho.in", "zohoapis.in"}},
+	RegionAU: {"api.zeptomail.com.au", "accounts.zoho.com.au", "smtp.zeptomail.com.au", []string{"zeptomail.com.au", "zoho.com.au", "zohoapis.com.au"}},
+	RegionJP: {"api.zeptomail.jp", "accounts.zoho.jp", "smtp.zeptomail.jp", []string{"zeptomail.jp", "zoho.jp", "zohoapis.jp"}},
+	RegionCA: {"api.zeptomail.ca", "accounts.zohocloud.ca", "smtp.zeptomail.ca", []string{"zeptomail.ca", "zohocloud.ca", "zohoapis.ca"}},
+	RegionSA: {"api.zeptomail.sa", "accounts.zoho.sa", "smtp.zeptomail.sa", []string{"zeptomail.sa", "zoho.sa", "zohoapis.sa"}},
+	RegionCN: {"api.zeptomail.com.cn", "accounts.zoho.com.cn", "smtp.zeptomail.com.cn", []string{"zeptomail.com.cn", "zoho.com.cn", "zohoapis.com.cn"}},
+}
+
+// ParseRegion parses a region code such as "eu" or "IN", e.g. from
+// configuration.
+func ParseRegion(s string) (Region, error) {
+	r := Region(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := regions[r]; !ok {
+		return "", fmt.Errorf("zeptomail: unknown region %q", s)
+	}
+	return r, nil
+}
+
+// BaseURL returns the ZeptoMail API base URL of the data center, or "" for
+// an unknown region.
+func (r Region) BaseURL() string {
+	h, ok := regions[r]
+	if !ok {
+		return ""
+	}
+	return "https://" + h.api + "/v1.1"
+}
+
+// AccountsURL returns the Zoho accounts server of the data center, which
+// issues OAuth tokens, or "" for an unknown region.
+func (r Region) AccountsURL() string {
+	h, ok := regions[r]
+	if !ok {
+		return ""
+	}
+	return "https://" + h.accounts
+}
+
+// SMTPHost returns the SMTP relay host of the data center, or "" for an
+// unknown region.
+func (r Region) SMTPHost() string {
+	return regions[r].smtp
+}
+
+// InferRegion guesses the data center from a URL or host name: the API host,
+// the accounts server (Zoho passes it as the accounts-server parameter of the
+// OAuth redirect) or the api_domain returned alongside OAuth tokens.
+//
+// ZeptoMail API keys and Zoho OAuth tokens do not encode their data center,
+// so for a bare key or token InferRegion reports false and the region has to
+// be configured explicitly.
+func InferRegion(s string) (Region, bool) {
+	s = strings.TrimSpace(s)
+	host := s
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return "", false
+		}
+		host = u.Hostname()
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for r, h := range regions {
+		for _, d := range h.domains {
+			if host == d || strings.HasSuffix(host, "."+d) {
+				return r, true
+			}
+		}
+	}
+	return "", false
+}
+
+// WithRegion points the client at the API host of the given data center, or
+// for an SMTPClient at its SMTP relay. A ZohoTokenSource passed to
+// NewTemplatesClientWithTokenSource without its own AccountsURL or Region
+// uses the matching accounts server. Unknown regions are ignored; use
+// ParseRegion to validate user input.
+func WithRegion(r Region) Option {
+	return func(cfg *clientConfig) {
+		if u := r.BaseURL(); u != "" {
+			cfg.baseURL = u
+			cfg.region = r
+		}
+	}
+}
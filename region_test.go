@@ -0,0 +1,133 @@
+package zeptomail
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegionURLs(t *testing.T) {
+	tests := []struct {
+		region   Region
+		base     string
+		accounts string
+	}{
+		{RegionUS, "https://api.zeptomail.com/v1.1", "https://accounts.zoho.com"},
+		{RegionEU, "https://api.zeptomail.eu/v1.1", "https://accounts.zoho.eu"},
+		{RegionIN, "https://api.zeptomail.in/v1.1", "https://accounts.zoho.in"},
+		{RegionAU, "https://api.zeptomail.com.au/v1.1", "https://accounts.zoho.com.au"},
+		{RegionJP, "https://api.zeptomail.jp/v1.1", "https://accounts.zoho.jp"},
+		{RegionCA, "https://api.zeptomail.ca/v1.1", "https://accounts.zohocloud.ca"},
+		{RegionSA, "https://api.zeptomail.sa/v1.1", "https://accounts.zoho.sa"},
+		{RegionCN, "https://api.zeptomail.com.cn/v1.1", "https://accounts.zoho.com.cn"},
+		{Region("xx"), "", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.region.BaseURL(); got != tt.base {
+			t.Errorf("%s.BaseURL() = %q, want %q", tt.region, got, tt.base)
+		}
+		if got := tt.region.AccountsURL(); got != tt.accounts {
+			t.Errorf("%s.AccountsURL() = %q, want %q", tt.region, got, tt.accounts)
+		}
+	}
+	if RegionCA.SMTPHost() != "smtp.zeptomail.ca" || Region("xx").SMTPHost() != "" {
+		t.Errorf("SMTPHost = %q", RegionCA.SMTPHost())
+	}
+	if RegionUS.BaseURL() != baseURL || RegionUS.AccountsURL() != DefaultAccountsURL {
+		t.Error("RegionUS does not match the defaults")
+	}
+}
+
+func TestParseRegion(t *testing.T) {
+	if r, err := ParseRegion(" EU "); err != nil || r != RegionEU {
+		t.Errorf("ParseRegion(EU) = %q, %v", r, err)
+	}
+	if _, err := ParseRegion("mars"); err == nil {
+		t.Error("expected an error for an unknown region")
+	}
+}
+
+func TestInferRegion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Region
+		ok   bool
+	}{
+		{"https://api.zeptomail.eu/v1.1", RegionEU, true},
+		{"https://accounts.zoho.in", RegionIN, true},
+		{"https://www.zohoapis.com.au", RegionAU, true},
+		{"accounts.zohocloud.ca", RegionCA, true},
+		{"api.zeptomail.com.cn", RegionCN, true},
+		{"api.zeptomail.com", RegionUS, true},
+		{"https://zeptomail.com.example.org", "", false},
+		{"Zoho-enczapikey wSsVR61xyz", "", false},
+		{"1000.abc.def", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := InferRegion(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("InferRegion(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestWithRegion(t *testing.T) {
+	if cfg := newClientConfig([]Option{WithRegion(RegionJP)}); cfg.baseURL != RegionJP.BaseURL() {
+		t.Errorf("baseURL = %q", cfg.baseURL)
+	}
+	if cfg := newClientConfig([]Option{WithRegion("xx")}); cfg.baseURL != baseURL || cfg.region != "" {
+		t.Errorf("unknown region changed the config: %q, %q", cfg.baseURL, cfg.region)
+	}
+	// A later WithBaseURL still wins, e.g. for tests.
+	if cfg := newClientConfig([]Option{WithRegion(RegionEU), WithBaseURL("http://local")}); cfg.baseURL != "http://local" {
+		t.Errorf("baseURL = %q", cfg.baseURL)
+	}
+}
+
+func TestZohoTokenSource_Region(t *testing.T) {
+	var host string
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+	}))
+	defer stub.Close()
+
+	// Route every request to the stub while keeping the requested host.
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r2 := r.Clone(r.Context())
+		r2.URL.Scheme = "http"
+		r2.URL.Host = stub.Listener.Addr().String()
+		r2.Host = r.URL.Host
+		return http.DefaultTransport.RoundTrip(r2)
+	})}
+
+	src := &ZohoTokenSource{Region: RegionEU, HTTPClient: httpClient}
+	if _, err := src.Token(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if host != "accounts.zoho.eu" {
+		t.Errorf("token requested from %q, want accounts.zoho.eu", host)
+	}
+
+	// WithRegion selects the accounts server of a ZohoTokenSource without one.
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	src = &ZohoTokenSource{HTTPClient: httpClient}
+	client := NewTemplatesClientWithTokenSource(src, WithRegion(RegionIN), WithBaseURL(api.URL))
+	if err := client.DeleteTemplate(context.Background(), "agent", "key"); err != nil {
+		t.Fatal(err)
+	}
+	if host != "accounts.zoho.in" {
+		t.Errorf("token requested from %q, want accounts.zoho.in", host)
+	}
+	if src.Region != "" {
+		t.Error("the caller's token source was modified")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
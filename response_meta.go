@@ -0,0 +1,72 @@
+package zeptomail
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go/internal/transport"
+)
+
+// ResponseMeta describes the HTTP response behind a client call. Capture it
+// with CaptureResponseMeta.
+type ResponseMeta struct {
+	// StatusCode, Header and Body are those of the last response, e.g.
+	// the final retry. StatusCode is zero and Header and Body are nil if
+	// no response was received, such as when validation failed.
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Duration is the time spent on HTTP requests, backoff between
+	// retries included.
+	Duration time.Duration
+	// Attempts is the number of requests sent, retries included.
+	Attempts int
+}
+
+type responseMetaKey struct{}
+
+// CaptureResponseMeta returns a context that makes client calls store
+// their ResponseMeta in meta, whether they succeed or fail:
+//
+//	var meta zeptomail.ResponseMeta
+//	resp, err := client.SendEmail(zeptomail.CaptureResponseMeta(ctx, &meta), req)
+//	log.Println(meta.StatusCode, meta.Header.Get("X-RateLimit-Remaining"))
+//
+// Each call overwrites meta, so a context shared by several calls holds
+// the meta of the one that finished last. Calls using the context must not
+// run concurrently. The chunked batch sends, which send concurrently,
+// ignore meta.
+func CaptureResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, responseMetaKey{}, meta)
+}
+
+// withoutResponseMeta returns a context in which calls capture no
+// ResponseMeta.
+func withoutResponseMeta(ctx context.Context) context.Context {
+	if ctx.Value(responseMetaKey{}) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, responseMetaKey{}, (*ResponseMeta)(nil))
+}
+
+// responseMetaMiddleware fills in the ResponseMeta requested with
+// CaptureResponseMeta.
+func responseMetaMiddleware(next Handler) Handler {
+	return func(ctx context.Context, call *Call) (interface{}, error) {
+		out, err := next(ctx, call)
+		if meta, ok := ctx.Value(responseMetaKey{}).(*ResponseMeta); ok && meta != nil {
+			*meta = ResponseMeta{}
+			if ex := transport.ExchangeFrom(ctx); ex != nil {
+				*meta = ResponseMeta{
+					StatusCode: ex.StatusCode,
+					Header:     ex.Header,
+					Body:       ex.Body,
+					Duration:   ex.Duration,
+					Attempts:   ex.Attempts,
+				}
+			}
+		}
+		return out, err
+	}
+}
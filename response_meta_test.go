@@ -0,0 +1,89 @@
+package zeptomail
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCaptureResponseMeta(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Write([]byte(`{"request_id":"req-1","message":"OK"}`))
+	}))
+	defer ts.Close()
+
+	client := newTestEmailClient(ts.URL)
+	var meta ResponseMeta
+	if _, err := client.SendEmail(CaptureResponseMeta(context.Background(), &meta), testEmailRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if meta.StatusCode != http.StatusOK || meta.Attempts != 1 || meta.Duration <= 0 {
+		t.Errorf("meta = %+v", meta)
+	}
+	if meta.Header.Get("X-RateLimit-Remaining") != "42" {
+		t.Errorf("header = %v", meta.Header)
+	}
+	if string(meta.Body) != `{"request_id":"req-1","message":"OK"}` {
+		t.Errorf("body = %s", meta.Body)
+	}
+
+	// A call that fails validation sends nothing and clears meta.
+	req := testEmailRequest()
+	req.Subject = ""
+	if _, err := client.SendEmail(CaptureResponseMeta(context.Background(), &meta), req); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if meta.StatusCode != 0 || meta.Header != nil || meta.Body != nil || meta.Attempts != 0 {
+		t.Errorf("meta after validation error = %+v", meta)
+	}
+}
+
+func TestCaptureResponseMeta_Error(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":{"code":"TM_5001","message":"Service unavailable"}}`))
+	}))
+	defer ts.Close()
+
+	client := NewTemplatesClient("test-oauth-token", WithBaseURL(ts.URL),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond}))
+	var meta ResponseMeta
+	if err := client.DeleteTemplate(CaptureResponseMeta(context.Background(), &meta), "agent", "key"); err == nil {
+		t.Fatal("expected error")
+	}
+	if meta.StatusCode != http.StatusServiceUnavailable || meta.Attempts != 3 {
+		t.Errorf("meta = %+v", meta)
+	}
+	if string(meta.Body) != `{"error":{"code":"TM_5001","message":"Service unavailable"}}` {
+		t.Errorf("body = %s", meta.Body)
+	}
+}
+
+// Run with -race: chunks are sent concurrently on the caller's context.
+func TestCaptureResponseMeta_Chunked(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"request_id":"req-1","message":"OK"}`))
+	}))
+	defer ts.Close()
+
+	req := testEmailRequest()
+	req.To = makeRecipients(40)
+	var meta ResponseMeta
+	ctx := CaptureResponseMeta(context.Background(), &meta)
+	res, err := newTestEmailClient(ts.URL).SendBatchEmailChunked(ctx, req, BatchOptions{ChunkSize: 5, Concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Chunks) != 8 {
+		t.Errorf("chunks = %d, want 8", len(res.Chunks))
+	}
+	if meta.StatusCode != 0 || meta.Attempts != 0 {
+		t.Errorf("meta = %+v, want it untouched", meta)
+	}
+}
@@ -0,0 +1,303 @@
+package zeptomail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go/internal/transport"
+)
+
+// DefaultSMTPAddr is the SMTP relay of the US data center. Port 587 uses
+// STARTTLS; port 465 uses implicit TLS.
+const DefaultSMTPAddr = "smtp.zeptomail.com:587"
+
+// smtpUsername is the fixed SMTP user name of ZeptoMail; the send mail
+// token is the password.
+const smtpUsername = "emailapikey"
+
+// SMTPClient sends EmailRequests through the ZeptoMail SMTP relay, for
+// networks where only SMTP is allowed out. It renders each request to MIME
+// and reports results and failures in the same shapes as EmailClient:
+// a *SuccessResponse, a *ValidationError, or an *APIError whose HTTP status
+// is derived from the SMTP reply code so that errors.Is works with the
+// sentinel errors.
+//
+// The connection is always encrypted and authenticated: a relay that does
+// not offer STARTTLS on a port other than 465, or does not offer AUTH, is
+// an error rather than a reason to send the token or the message in the
+// clear.
+type SMTPClient struct {
+	addr       string
+	apiKey     string
+	tlsConfig  *tls.Config
+	insecure   bool
+	timeout    time.Duration
+	validate   bool
+	limiter    *RateLimiter
+	middleware []Middleware
+}
+
+// SMTPOption tweaks SMTPClient behaviour. Pass to NewSMTPClient.
+type SMTPOption func(*smtpConfig)
+
+type smtpConfig struct {
+	client    []Option
+	addr      string
+	tlsConfig *tls.Config
+	insecure  bool
+}
+
+// WithClientOptions applies client options to an SMTPClient. Of those,
+// WithRegion, WithHTTPClient (for its timeout), WithValidation,
+// WithRateLimit, WithRateLimiter, WithMiddleware, WithLogger, WithTracer and
+// WithMetrics apply; the others only concern HTTP and are ignored.
+func WithClientOptions(opts ...Option) SMTPOption {
+	return func(cfg *smtpConfig) {
+		cfg.client = append(cfg.client, opts...)
+	}
+}
+
+// WithSMTPAddr sets the host:port of the SMTP relay, overriding the one
+// chosen by WithRegion.
+func WithSMTPAddr(addr string) SMTPOption {
+	return func(cfg *smtpConfig) {
+		cfg.addr = addr
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of an SMTPClient, e.g. to trust
+// a private CA. The server name is filled in when empty.
+func WithTLSConfig(c *tls.Config) SMTPOption {
+	return func(cfg *smtpConfig) {
+		cfg.tlsConfig = c
+	}
+}
+
+// WithInsecureSMTP lets an SMTPClient talk to a relay that does not offer
+// STARTTLS, such as zeptomailtest.SMTPServer. AUTH is still required, and
+// net/smtp only sends the token over a plaintext connection to localhost.
+// It is meant for tests; never use it with a real relay.
+func WithInsecureSMTP() SMTPOption {
+	return func(cfg *smtpConfig) {
+		cfg.insecure = true
+	}
+}
+
+// NewSMTPClient returns a client that authenticates to the SMTP relay with
+// the given send mail token.
+func NewSMTPClient(apiKey string, opts ...SMTPOption) *SMTPClient {
+	var scfg smtpConfig
+	for _, opt := range opts {
+		opt(&scfg)
+	}
+	cfg := newClientConfig(scfg.client)
+	addr := scfg.addr
+	if addr == "" && cfg.region != "" {
+		addr = net.JoinHostPort(cfg.region.SMTPHost(), "587")
+	}
+	if addr == "" {
+		addr = DefaultSMTPAddr
+	}
+	return &SMTPClient{
+		addr:       addr,
+		apiKey:     strings.TrimPrefix(apiKey, "Zoho-enczapikey "),
+		tlsConfig:  scfg.tlsConfig,
+		insecure:   scfg.insecure,
+		timeout:    cfg.httpClient.Timeout,
+		validate:   !cfg.skipValidation,
+		limiter:    cfg.rateLimiter,
+		middleware: cfg.allMiddleware(),
+	}
+}
+
+// SendEmail renders req and sends it to all To, Cc and Bcc recipients.
+// Inline attachments and images must carry their content, as SMTP has no
+// access to the file cache. SMTP returns no request ID, so RequestID of the
+// response holds the generated Message-ID instead.
+func (c *SMTPClient) SendEmail(ctx context.Context, req *EmailRequest) (*SuccessResponse, error) {
+	call := &transport.Call{Operation: "SendEmail", Method: "SMTP", Path: c.addr, Payload: req}
+	var h Handler = func(ctx context.Context, call *transport.Call) (interface{}, error) {
+		req, ok := call.Payload.(*EmailRequest)
+		if !ok {
+			return nil, fmt.Errorf("zeptomail: SendEmail: unexpected payload type %T", call.Payload)
+		}
+		if c.validate {
+			if err := req.validate(false); err != nil {
+				return nil, err
+			}
+		}
+		return c.send(ctx, req)
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+
+	out, err := h(transport.WithExchange(ctx, &transport.Exchange{}), call)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := out.(*SuccessResponse)
+	if !ok {
+		return nil, fmt.Errorf("zeptomail: SendEmail: unexpected result type %T", out)
+	}
+	return v, nil
+}
+
+func (c *SMTPClient) send(ctx context.Context, req *EmailRequest) (*SuccessResponse, error) {
+	id := newMessageID(req.From.Address)
+	var msg bytes.Buffer
+	if err := writeMIME(&msg, req, mimeMessage{messageID: id, date: time.Now()}); err != nil {
+		return nil, err
+	}
+
+	from := req.BounceAddress
+	if from == "" {
+		from = req.From.Address
+	}
+	var rcpts []string
+	for _, list := range [][]Recipient{req.To, req.Cc, req.Bcc} {
+		for _, r := range list {
+			rcpts = append(rcpts, r.Address)
+		}
+	}
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	if err := c.deliver(ctx, from, rcpts, msg.Bytes()); err != nil {
+		return nil, err
+	}
+	return &SuccessResponse{
+		Data:      []ResponseData{{Code: "EM_104", Message: "Email request received"}},
+		Message:   "OK",
+		RequestID: strings.Trim(id, "<>"),
+		Object:    "email",
+	}, nil
+}
+
+// deliver runs one SMTP transaction. The context bounds the whole
+// conversation: its deadline, or the client timeout, becomes the connection
+// deadline and cancelling it aborts the connection.
+func (c *SMTPClient) deliver(ctx context.Context, from string, rcpts []string, msg []byte) (err error) {
+	host, port, err := net.SplitHostPort(c.addr)
+	if err != nil {
+		return fmt.Errorf("zeptomail: smtp address %q: %w", c.addr, err)
+	}
+	tlsConfig := c.tlsConfig.Clone()
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var conn net.Conn
+	if port == "465" {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", c.addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", c.addr)
+	}
+	if err != nil {
+		return smtpError(ctx, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return smtpError(ctx, err)
+	}
+	defer client.Close()
+
+	if port != "465" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return smtpError(ctx, err)
+			}
+		} else if !c.insecure {
+			return fmt.Errorf("zeptomail: smtp: %s does not offer STARTTLS", c.addr)
+		}
+	}
+	if ok, _ := client.Extension("AUTH"); !ok {
+		return fmt.Errorf("zeptomail: smtp: %s does not offer AUTH", c.addr)
+	}
+	if err := client.Auth(smtp.PlainAuth("", smtpUsername, c.apiKey, host)); err != nil {
+		return smtpError(ctx, err)
+	}
+	if err := client.Mail(from); err != nil {
+		return smtpError(ctx, err)
+	}
+	for _, rcpt := range rcpts {
+		if err := client.Rcpt(rcpt); err != nil {
+			return smtpError(ctx, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return smtpError(ctx, err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return smtpError(ctx, err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError(ctx, err)
+	}
+	// The relay has accepted the message; a failing QUIT does not change that.
+	client.Quit()
+	return nil
+}
+
+// smtpError converts SMTP replies into *APIError values. Transient 4xx
+// replies map to 503 so that IsRetryable holds, authentication failures to
+// 401 and other permanent failures to 400. Network errors are returned
+// wrapped, and context errors take precedence.
+func smtpError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	var tpErr *textproto.Error
+	if !errors.As(err, &tpErr) {
+		return fmt.Errorf("zeptomail: smtp: %w", err)
+	}
+	status := http.StatusBadRequest
+	switch {
+	case tpErr.Code >= 400 && tpErr.Code < 500:
+		status = http.StatusServiceUnavailable
+	case tpErr.Code == 530 || tpErr.Code == 534 || tpErr.Code == 535:
+		status = http.StatusUnauthorized
+	}
+	return &APIError{
+		HTTPStatusCode: status,
+		Code:           fmt.Sprintf("SMTP_%d", tpErr.Code),
+		Message:        tpErr.Msg,
+	}
+}
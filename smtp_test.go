@@ -0,0 +1,178 @@
+package zeptomail_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go"
+	"github.com/navnitms/zeptomail-sdk-go/zeptomailtest"
+)
+
+func smtpTestRequest() *zeptomail.EmailRequest {
+	return &zeptomail.EmailRequest{
+		From:          zeptomail.EmailAddress{Address: "sender@example.com", Name: "Sender"},
+		To:            []zeptomail.Recipient{{EmailAddress: zeptomail.EmailAddress{Address: "to@example.org"}}},
+		Cc:            []zeptomail.Recipient{{EmailAddress: zeptomail.EmailAddress{Address: "cc@example.org"}}},
+		Bcc:           []zeptomail.Recipient{{EmailAddress: zeptomail.EmailAddress{Address: "bcc@example.org"}}},
+		Subject:       "Over SMTP",
+		HTMLBody:      "<p>Hello</p>",
+		TextBody:      "Hello",
+		BounceAddress: "bounces@bounce.example.com",
+	}
+}
+
+func TestSMTPClient_SendEmail(t *testing.T) {
+	srv := zeptomailtest.NewSMTPServer()
+	defer srv.Close()
+	srv.APIKey = "secret-key"
+
+	var ops []string
+	client := srv.Client(zeptomail.WithClientOptions(zeptomail.WithMiddleware(func(next zeptomail.Handler) zeptomail.Handler {
+		return func(ctx context.Context, call *zeptomail.Call) (interface{}, error) {
+			ops = append(ops, call.Operation+" "+call.Method)
+			return next(ctx, call)
+		}
+	})))
+	resp, err := client.SendEmail(context.Background(), smtpTestRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID == "" || resp.Message != "OK" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(ops) != 1 || ops[0] != "SendEmail SMTP" {
+		t.Errorf("middleware saw %v", ops)
+	}
+
+	msgs := srv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	m := msgs[0]
+	if m.From != "bounces@bounce.example.com" {
+		t.Errorf("envelope sender = %q", m.From)
+	}
+	if strings.Join(m.To, ",") != "to@example.org,cc@example.org,bcc@example.org" {
+		t.Errorf("envelope recipients = %v", m.To)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(string(m.Data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header.Get("Subject") != "Over SMTP" || parsed.Header.Get("Message-Id") != "<"+resp.RequestID+">" {
+		t.Errorf("headers: %v", parsed.Header)
+	}
+	if !strings.HasPrefix(parsed.Header.Get("Content-Type"), "multipart/alternative;") {
+		t.Errorf("Content-Type = %q", parsed.Header.Get("Content-Type"))
+	}
+}
+
+func TestSMTPClient_Errors(t *testing.T) {
+	srv := zeptomailtest.NewSMTPServer()
+	defer srv.Close()
+	srv.APIKey = "secret-key"
+	ctx := context.Background()
+
+	_, err := zeptomail.NewSMTPClient("wrong", zeptomail.WithSMTPAddr(srv.Addr), zeptomail.WithInsecureSMTP()).SendEmail(ctx, smtpTestRequest())
+	var apiErr *zeptomail.APIError
+	if !errors.Is(err, zeptomail.ErrUnauthorized) || !errors.As(err, &apiErr) || apiErr.Code != "SMTP_535" {
+		t.Errorf("wrong key: %v", err)
+	}
+
+	client := srv.Client()
+	srv.FailNext(451, "4.3.0 try again later")
+	if _, err := client.SendEmail(ctx, smtpTestRequest()); !zeptomail.IsRetryable(err) {
+		t.Errorf("451: %v, want a retryable error", err)
+	}
+	srv.FailNext(550, "5.7.1 sender not allowed")
+	if _, err := client.SendEmail(ctx, smtpTestRequest()); !errors.Is(err, zeptomail.ErrInvalidRequest) || !zeptomail.IsPermanent(err) {
+		t.Errorf("550: %v, want a permanent invalid request error", err)
+	}
+
+	req := smtpTestRequest()
+	req.Subject = ""
+	var verr *zeptomail.ValidationError
+	if _, err := client.SendEmail(ctx, req); !errors.As(err, &verr) {
+		t.Errorf("invalid request: %v, want a *ValidationError", err)
+	}
+	if n := len(srv.Messages()); n != 0 {
+		t.Errorf("%d messages accepted, want 0", n)
+	}
+}
+
+func TestSMTPClient_ContextCancelled(t *testing.T) {
+	srv := zeptomailtest.NewSMTPServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+	if _, err := srv.Client().SendEmail(ctx, smtpTestRequest()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestSMTPClient_RequiresSTARTTLS(t *testing.T) {
+	srv := zeptomailtest.NewSMTPServer()
+	defer srv.Close()
+
+	_, err := zeptomail.NewSMTPClient("key", zeptomail.WithSMTPAddr(srv.Addr)).SendEmail(context.Background(), smtpTestRequest())
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("err = %v, want a missing STARTTLS error", err)
+	}
+	if n := len(srv.Messages()); n != 0 {
+		t.Errorf("%d messages accepted, want 0", n)
+	}
+}
+
+func TestSMTPClient_RequiresAUTH(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	mailed := make(chan bool, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		c := textproto.NewConn(conn)
+		c.PrintfLine("220 noauth ESMTP ready")
+		sawMail := false
+		for {
+			line, err := c.ReadLine()
+			if err != nil {
+				break
+			}
+			switch verb, _, _ := strings.Cut(line, " "); strings.ToUpper(verb) {
+			case "EHLO":
+				c.PrintfLine("250 noauth")
+			case "MAIL":
+				sawMail = true
+				c.PrintfLine("250 OK")
+			case "QUIT":
+				c.PrintfLine("221 bye")
+			default:
+				c.PrintfLine("250 OK")
+			}
+		}
+		mailed <- sawMail
+	}()
+
+	client := zeptomail.NewSMTPClient("key", zeptomail.WithSMTPAddr(ln.Addr().String()), zeptomail.WithInsecureSMTP())
+	_, err = client.SendEmail(context.Background(), smtpTestRequest())
+	if err == nil || !strings.Contains(err.Error(), "AUTH") {
+		t.Errorf("err = %v, want a missing AUTH error", err)
+	}
+	if <-mailed {
+		t.Error("client sent MAIL without authenticating")
+	}
+}
@@ -0,0 +1,110 @@
+package zeptomail
+
+import (
+	"context"
+	"errors"
+)
+
+// templatesPageSize is the page size used when iterating over templates.
+const templatesPageSize = 50
+
+// errStopIteration ends ListAllTemplates once the cap is reached.
+var errStopIteration = errors.New("stop iteration")
+
+// TemplateIterator pages through the templates of a mail agent. Use it as:
+//
+//	it := tc.Templates(ctx, "mailagent-alias")
+//	for it.Next() {
+//		tpl := it.Item()
+//		...
+//	}
+//	if err := it.Err(); err != nil { ... }
+type TemplateIterator struct {
+	ctx   context.Context
+	tc    *TemplatesClient
+	alias string
+
+	offset int
+	page   []TemplateListItem
+	item   TemplateListItem
+	last   bool
+	err    error
+}
+
+// Templates returns an iterator over every template of the mail agent,
+// fetching pages lazily as Next is called.
+func (tc *TemplatesClient) Templates(ctx context.Context, mailagentAlias string) *TemplateIterator {
+	return &TemplateIterator{ctx: ctx, tc: tc, alias: mailagentAlias}
+}
+
+// Next advances to the next template, fetching a new page when needed. It
+// returns false when there are no more templates or a request failed.
+func (it *TemplateIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if len(it.page) == 0 {
+		if it.last {
+			return false
+		}
+		it.fetch()
+		if it.err != nil || len(it.page) == 0 {
+			return false
+		}
+	}
+	it.item, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Item returns the current template.
+func (it *TemplateIterator) Item() TemplateListItem {
+	return it.item
+}
+
+// Err returns the first error encountered while paging.
+func (it *TemplateIterator) Err() error {
+	return it.err
+}
+
+func (it *TemplateIterator) fetch() {
+	resp, err := it.tc.ListTemplates(it.ctx, it.alias, ListTemplatesParams{Offset: it.offset, Limit: templatesPageSize})
+	if err != nil {
+		it.err = err
+		return
+	}
+	it.page = resp.Data
+	it.offset += len(resp.Data)
+	// Metadata.Count is not documented as the total number of templates,
+	// so only a short page ends the listing. A full last page costs one
+	// extra request that returns nothing.
+	it.last = len(resp.Data) < templatesPageSize
+}
+
+// ForEachTemplate calls fn for every template of the mail agent, stopping at
+// the first error returned by fn or by the API.
+func (tc *TemplatesClient) ForEachTemplate(ctx context.Context, mailagentAlias string, fn func(TemplateListItem) error) error {
+	it := tc.Templates(ctx, mailagentAlias)
+	for it.Next() {
+		if err := fn(it.Item()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// ListAllTemplates collects the mail agent's templates. When limit is
+// positive at most limit templates are returned.
+func (tc *TemplatesClient) ListAllTemplates(ctx context.Context, mailagentAlias string, limit int) ([]TemplateListItem, error) {
+	var all []TemplateListItem
+	err := tc.ForEachTemplate(ctx, mailagentAlias, func(item TemplateListItem) error {
+		all = append(all, item)
+		if limit > 0 && len(all) >= limit {
+			return errStopIteration
+		}
+		return nil
+	})
+	if err != nil && err != errStopIteration {
+		return nil, err
+	}
+	return all, nil
+}
@@ -0,0 +1,152 @@
+package zeptomail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// templateListServer serves n templates, paginated by offset and limit,
+// with the total in Metadata.Count.
+func templateListServer(t *testing.T, n int, requests *int) *httptest.Server {
+	t.Helper()
+	return templateListServerCount(t, n, func(int) int { return n }, requests)
+}
+
+// templateListServerCount is templateListServer with Metadata.Count set by
+// count from the page size.
+func templateListServerCount(t *testing.T, n int, count func(limit int) int, requests *int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		resp := ListTemplatesResponse{Metadata: ListTemplatesMetadata{Offset: offset, Limit: limit, Count: count(limit)}}
+		for i := offset; i < n && i < offset+limit; i++ {
+			resp.Data = append(resp.Data, TemplateListItem{TemplateKey: fmt.Sprintf("k%d", i)})
+		}
+		w.WriteHeader(200)
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestTemplates_IteratesAllPages(t *testing.T) {
+	requests := 0
+	ts := templateListServer(t, 2*templatesPageSize+3, &requests)
+	defer ts.Close()
+
+	client := newTestTemplatesClient(ts.URL)
+	it := client.Templates(context.Background(), "agent")
+	var keys []string
+	for it.Next() {
+		keys = append(keys, it.Item().TemplateKey)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2*templatesPageSize+3 {
+		t.Errorf("got %d templates, want %d", len(keys), 2*templatesPageSize+3)
+	}
+	if keys[0] != "k0" || keys[len(keys)-1] != fmt.Sprintf("k%d", len(keys)-1) {
+		t.Errorf("unexpected order: first %q, last %q", keys[0], keys[len(keys)-1])
+	}
+	if requests != 3 {
+		t.Errorf("requests = %d, want 3", requests)
+	}
+}
+
+func TestTemplates_ExactPageMultiple(t *testing.T) {
+	requests := 0
+	ts := templateListServer(t, templatesPageSize, &requests)
+	defer ts.Close()
+
+	all, err := newTestTemplatesClient(ts.URL).ListAllTemplates(context.Background(), "agent", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != templatesPageSize || requests != 2 {
+		t.Errorf("got %d templates in %d requests, want %d in 2", len(all), requests, templatesPageSize)
+	}
+}
+
+func TestTemplates_CountIsPageSize(t *testing.T) {
+	requests := 0
+	ts := templateListServerCount(t, 2*templatesPageSize+3, func(limit int) int { return limit }, &requests)
+	defer ts.Close()
+
+	all, err := newTestTemplatesClient(ts.URL).ListAllTemplates(context.Background(), "agent", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2*templatesPageSize+3 || requests != 3 {
+		t.Errorf("got %d templates in %d requests, want %d in 3", len(all), requests, 2*templatesPageSize+3)
+	}
+}
+
+func TestTemplates_Empty(t *testing.T) {
+	requests := 0
+	ts := templateListServer(t, 0, &requests)
+	defer ts.Close()
+
+	it := newTestTemplatesClient(ts.URL).Templates(context.Background(), "agent")
+	if it.Next() {
+		t.Error("Next() = true on empty list")
+	}
+	if it.Err() != nil {
+		t.Error(it.Err())
+	}
+}
+
+func TestTemplates_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(401)
+		w.Write([]byte(`{"error":{"code":"INVALID_OAUTHTOKEN","message":"invalid"}}`))
+	}))
+	defer ts.Close()
+
+	it := newTestTemplatesClient(ts.URL).Templates(context.Background(), "agent")
+	if it.Next() {
+		t.Error("Next() = true despite error")
+	}
+	if !errors.Is(it.Err(), ErrUnauthorized) {
+		t.Errorf("Err() = %v, want unauthorized", it.Err())
+	}
+}
+
+func TestListAllTemplates_Cap(t *testing.T) {
+	requests := 0
+	ts := templateListServer(t, 3*templatesPageSize, &requests)
+	defer ts.Close()
+
+	all, err := newTestTemplatesClient(ts.URL).ListAllTemplates(context.Background(), "agent", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 10 || requests != 1 {
+		t.Errorf("got %d templates in %d requests, want 10 in 1", len(all), requests)
+	}
+}
+
+func TestForEachTemplate_StopsOnError(t *testing.T) {
+	requests := 0
+	ts := templateListServer(t, 5, &requests)
+	defer ts.Close()
+
+	stop := errors.New("stop")
+	seen := 0
+	err := newTestTemplatesClient(ts.URL).ForEachTemplate(context.Background(), "agent", func(TemplateListItem) error {
+		seen++
+		if seen == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || seen != 2 {
+		t.Errorf("err = %v after %d items, want stop after 2", err, seen)
+	}
+}
@@ -0,0 +1,134 @@
+package zeptomail
+
+import (
+	"context"
+	"errors"
+
+	"github.com/navnitms/zeptomail-sdk-go/internal/transport"
+)
+
+// Tracer starts spans around client operations. It is deliberately small
+// so that any tracing library can be adapted without the SDK depending on
+// it; the github.com/navnitms/zeptomail-sdk-go/otel module adapts
+// OpenTelemetry.
+type Tracer interface {
+	// Start begins a span as a child of any span in ctx and returns a
+	// context carrying the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is an operation in progress.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// RecordError marks the span as failed.
+	RecordError(err error)
+	// TraceParent returns the span's W3C trace context as a traceparent
+	// header value, or "" to send no header.
+	TraceParent() string
+	End()
+}
+
+// Attribute is a key-value pair recorded on a span. Values are strings,
+// ints, int64s or bools.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Attribute keys recorded by WithTracer.
+const (
+	AttrOperation       = "zeptomail.operation"
+	AttrRequestID       = "zeptomail.request_id"
+	AttrErrorCode       = "zeptomail.error_code"
+	AttrRecipients      = "zeptomail.recipients"
+	AttrAttachmentBytes = "zeptomail.attachment_bytes"
+	AttrAttempts        = "zeptomail.attempts"
+	AttrHTTPMethod      = "http.request.method"
+	AttrHTTPStatus      = "http.response.status_code"
+	AttrURLPath         = "url.path"
+)
+
+// WithTracer wraps every client operation in a span named
+// "zeptomail.<Operation>", e.g. "zeptomail.SendEmail", recording the
+// operation, method, path, HTTP status, attempts, request_id, recipient
+// count, attachment size and error code. Requests carry the span's W3C
+// traceparent header.
+func WithTracer(t Tracer) Option {
+	return func(cfg *clientConfig) {
+		cfg.tracer = tracingMiddleware(t)
+	}
+}
+
+func tracingMiddleware(t Tracer) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (interface{}, error) {
+			ctx, span := t.Start(ctx, "zeptomail."+call.Operation)
+			defer span.End()
+
+			span.SetAttributes(
+				Attribute{AttrOperation, call.Operation},
+				Attribute{AttrHTTPMethod, call.Method},
+				Attribute{AttrURLPath, call.Path},
+			)
+			span.SetAttributes(payloadAttributes(call.Payload)...)
+			ex := transport.ExchangeFrom(ctx)
+			if tp := span.TraceParent(); tp != "" && ex != nil {
+				ex.SetHeader("traceparent", tp)
+			}
+
+			out, err := next(ctx, call)
+
+			if ex != nil && ex.StatusCode != 0 {
+				span.SetAttributes(Attribute{AttrHTTPStatus, ex.StatusCode}, Attribute{AttrAttempts, ex.Attempts})
+			}
+			if id := requestID(out, err); id != "" {
+				span.SetAttributes(Attribute{AttrRequestID, id})
+			}
+			if err != nil {
+				var apiErr *APIError
+				if errors.As(err, &apiErr) {
+					span.SetAttributes(Attribute{AttrErrorCode, apiErr.Code})
+				}
+				span.RecordError(err)
+			}
+			return out, err
+		}
+	}
+}
+
+// payloadAttributes records the recipients and attachment bytes of a send
+// or upload.
+func payloadAttributes(p interface{}) []Attribute {
+	recipients, size, ok := payloadCounts(p)
+	if !ok {
+		return nil
+	}
+	return []Attribute{{AttrRecipients, recipients}, {AttrAttachmentBytes, size}}
+}
+
+// payloadCounts returns the recipient count and the attachment or file
+// bytes of a send or upload payload. ok is false for other payloads.
+func payloadCounts(p interface{}) (recipients int, size int64, ok bool) {
+	switch p := p.(type) {
+	case *EmailRequest:
+		return len(p.To) + len(p.Cc) + len(p.Bcc), attachmentBytes(p.Attachments, p.InlineImages), true
+	case *TemplateRequest:
+		return len(p.To) + len(p.Cc) + len(p.Bcc), attachmentBytes(p.Attachments, p.InlineImages), true
+	case *FileUploadRequest:
+		return 0, p.size(), true
+	}
+	return 0, 0, false
+}
+
+// attachmentBytes returns the decoded size of the inline content of
+// attachments and images.
+func attachmentBytes(attachments []Attachment, images []InlineImage) int64 {
+	var n int64
+	for _, a := range attachments {
+		n += int64(decodedLen(a.Content))
+	}
+	for _, img := range images {
+		n += int64(decodedLen(img.Content))
+	}
+	return n
+}
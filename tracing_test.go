@@ -0,0 +1,115 @@
+package zeptomail
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordedSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) { s.err = err }
+func (s *recordedSpan) End()                  { s.ended = true }
+
+func (s *recordedSpan) TraceParent() string {
+	return "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+}
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &recordedSpan{name: name, attrs: make(map[string]interface{})}
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return ctx, s
+}
+
+func TestWithTracer_Success(t *testing.T) {
+	var traceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{"request_id":"req-1","message":"OK"}`))
+	}))
+	defer ts.Close()
+
+	tracer := &recordingTracer{}
+	client := NewEmailClient("test-api-key", WithBaseURL(ts.URL), WithTracer(tracer))
+	req := testEmailRequest()
+	req.Cc = []Recipient{{EmailAddress: EmailAddress{Address: "e@f.com"}}}
+	req.Attachments = []Attachment{{Name: "a.txt", Content: "aGVsbG8="}}
+	if _, err := client.SendEmail(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	if traceparent != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("traceparent = %q", traceparent)
+	}
+	if len(tracer.spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "zeptomail.SendEmail" || !span.ended || span.err != nil {
+		t.Errorf("span = %+v", span)
+	}
+	want := map[string]interface{}{
+		AttrOperation:       "SendEmail",
+		AttrHTTPMethod:      http.MethodPost,
+		AttrURLPath:         "/email",
+		AttrHTTPStatus:      200,
+		AttrAttempts:        1,
+		AttrRequestID:       "req-1",
+		AttrRecipients:      2,
+		AttrAttachmentBytes: int64(5),
+	}
+	for k, v := range want {
+		if span.attrs[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, span.attrs[k], v)
+		}
+	}
+}
+
+func TestWithTracer_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":{"code":"TM_5001","message":"Service unavailable","request_id":"req-2"}}`))
+	}))
+	defer ts.Close()
+
+	tracer := &recordingTracer{}
+	client := NewEmailClient("test-api-key", WithBaseURL(ts.URL), WithTracer(tracer),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond}))
+	_, err := client.SendEmail(context.Background(), testEmailRequest())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	span := tracer.spans[0]
+	if !errors.Is(span.err, err) {
+		t.Errorf("recorded error = %v", span.err)
+	}
+	if span.attrs[AttrHTTPStatus] != 503 || span.attrs[AttrAttempts] != 2 {
+		t.Errorf("status = %v, attempts = %v", span.attrs[AttrHTTPStatus], span.attrs[AttrAttempts])
+	}
+	if span.attrs[AttrErrorCode] != "TM_5001" || span.attrs[AttrRequestID] != "req-2" {
+		t.Errorf("attrs = %v", span.attrs)
+	}
+}
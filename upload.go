@@ -0,0 +1,154 @@
+package zeptomail
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/navnitms/zeptomail-sdk-go/internal/transport"
+)
+
+// UploadOption configures FileCacheUploadReader.
+type UploadOption func(*uploadConfig)
+
+type uploadConfig struct {
+	contentType string
+	progress    func(sent, total int64)
+}
+
+// WithContentType sets the upload's Content-Type instead of sniffing it from
+// the first 512 bytes of the body.
+func WithContentType(contentType string) UploadOption {
+	return func(cfg *uploadConfig) {
+		cfg.contentType = contentType
+	}
+}
+
+// WithProgress registers fn to be called as the body is streamed. total is
+// the size passed to FileCacheUploadReader, or -1 when it is unknown. When an
+// upload is retried, sent starts again from zero.
+func WithProgress(fn func(sent, total int64)) UploadOption {
+	return func(cfg *uploadConfig) {
+		cfg.progress = fn
+	}
+}
+
+// size returns the length of the upload, or 0 when a streamed upload has
+// an unknown size.
+func (p *FileUploadRequest) size() int64 {
+	if p.Content != nil {
+		return int64(len(p.Content))
+	}
+	if p.Size < 0 {
+		return 0
+	}
+	return p.Size
+}
+
+// FileCacheUploadReader streams r to the file cache without loading it into
+// memory. size is the body length in bytes, or -1 if unknown. The upload is
+// only retried when r also implements io.Seeker.
+func (ec *EmailClient) FileCacheUploadReader(ctx context.Context, filename string, r io.Reader, size int64, opts ...UploadOption) (*FileUploadResponse, error) {
+	cfg := &uploadConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if size <= 0 {
+		size = -1
+	}
+
+	body := r
+	if cfg.contentType == "" {
+		var err error
+		if body, cfg.contentType, err = sniffContentType(r); err != nil {
+			return nil, fmt.Errorf("zeptomail: reading upload: %w", err)
+		}
+	}
+	if cfg.progress != nil {
+		body = newProgressReader(body, size, cfg.progress)
+	}
+
+	call := &transport.Call{
+		Operation: "FileCacheUpload",
+		Method:    "POST",
+		Path:      filesEndpoint,
+		Payload: &FileUploadRequest{
+			Filename:    filename,
+			Reader:      body,
+			Size:        size,
+			ContentType: cfg.contentType,
+		},
+	}
+	return invoke[FileUploadResponse](ctx, ec.httpClient, call, func(ctx context.Context, call *transport.Call) (*transport.Response, error) {
+		upload := call.Payload.(*FileUploadRequest)
+		return ec.httpClient.UploadReader(ctx, call.Path, upload.Filename, upload.ContentType, upload.Reader, upload.Size)
+	})
+}
+
+// sniffContentType detects the content type of r from its first 512 bytes
+// and returns a reader that still yields the whole content. Seekable readers
+// are rewound rather than buffered so that they stay retryable.
+func sniffContentType(r io.Reader) (io.Reader, string, error) {
+	if rs, ok := r.(io.ReadSeeker); ok {
+		start, err := rs.Seek(0, io.SeekCurrent)
+		if err == nil {
+			head := make([]byte, 512)
+			n, err := io.ReadFull(rs, head)
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return nil, "", err
+			}
+			if _, err := rs.Seek(start, io.SeekStart); err != nil {
+				return nil, "", err
+			}
+			return rs, http.DetectContentType(head[:n]), nil
+		}
+	}
+
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+	return br, http.DetectContentType(head), nil
+}
+
+// progressReader reports the number of bytes read so far.
+type progressReader struct {
+	r     io.Reader
+	total int64
+	sent  int64
+	fn    func(sent, total int64)
+}
+
+// progressReadSeeker is a progressReader over a seekable body. Seeking
+// restarts the count so retried uploads report from zero.
+type progressReadSeeker struct {
+	*progressReader
+}
+
+func newProgressReader(r io.Reader, total int64, fn func(sent, total int64)) io.Reader {
+	pr := &progressReader{r: r, total: total, fn: fn}
+	if _, ok := r.(io.Seeker); ok {
+		return progressReadSeeker{pr}
+	}
+	return pr
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.fn(p.sent, p.total)
+	}
+	return n, err
+}
+
+func (p progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := p.r.(io.Seeker).Seek(offset, whence)
+	if err == nil {
+		p.sent = 0
+	}
+	return pos, err
+}
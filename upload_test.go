@@ -0,0 +1,125 @@
+package zeptomail
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileCacheUploadReader_Streams(t *testing.T) {
+	var gotBody []byte
+	var gotType string
+	var gotLength int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotType = r.Header.Get("Content-Type")
+		gotLength = r.ContentLength
+		w.WriteHeader(200)
+		w.Write([]byte(`{"file_cache_key":"fck-1"}`))
+	}))
+	defer ts.Close()
+
+	content := "%PDF-1.4 " + strings.Repeat("x", 2000)
+	client := newTestEmailClient(ts.URL)
+	// Wrap in a plain io.Reader so the content is sniffed through a buffer.
+	r := io.MultiReader(strings.NewReader(content))
+	resp, err := client.FileCacheUploadReader(context.Background(), "doc.pdf", r, int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.FileCacheKey != "fck-1" {
+		t.Errorf("FileCacheKey = %q", resp.FileCacheKey)
+	}
+	if string(gotBody) != content {
+		t.Errorf("body length = %d, want %d", len(gotBody), len(content))
+	}
+	if gotType != "application/pdf" {
+		t.Errorf("Content-Type = %q, want application/pdf", gotType)
+	}
+	if gotLength != int64(len(content)) {
+		t.Errorf("Content-Length = %d, want %d", gotLength, len(content))
+	}
+}
+
+func TestFileCacheUploadReader_ExplicitContentTypeAndProgress(t *testing.T) {
+	var gotType string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		gotType = r.Header.Get("Content-Type")
+		w.WriteHeader(200)
+		w.Write([]byte(`{"file_cache_key":"fck-1"}`))
+	}))
+	defer ts.Close()
+
+	content := bytes.Repeat([]byte("a"), 100000)
+	var last, total int64
+	client := newTestEmailClient(ts.URL)
+	_, err := client.FileCacheUploadReader(context.Background(), "data.csv", bytes.NewReader(content), -1,
+		WithContentType("text/csv"),
+		WithProgress(func(sent, t int64) { last, total = sent, t }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotType != "text/csv" {
+		t.Errorf("Content-Type = %q, want text/csv", gotType)
+	}
+	if last != int64(len(content)) || total != -1 {
+		t.Errorf("progress = %d/%d, want %d/-1", last, total, len(content))
+	}
+}
+
+func TestFileCacheUploadReader_RetriesSeekable(t *testing.T) {
+	calls := 0
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		calls++
+		if calls == 1 {
+			w.WriteHeader(503)
+			return
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(`{"file_cache_key":"fck-1"}`))
+	}))
+	defer ts.Close()
+
+	client := NewEmailClient("key", WithBaseURL(ts.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond}))
+	var sentAtEnd int64
+	_, err := client.FileCacheUploadReader(context.Background(), "a.txt", strings.NewReader("payload"), 7,
+		WithProgress(func(sent, total int64) { sentAtEnd = sent }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 || bodies[0] != "payload" || bodies[1] != "payload" {
+		t.Errorf("bodies = %q, want payload twice", bodies)
+	}
+	if sentAtEnd != 7 {
+		t.Errorf("progress after retry = %d, want 7", sentAtEnd)
+	}
+}
+
+func TestFileCacheUploadReader_NoRetryForPlainReader(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		calls++
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	client := NewEmailClient("key", WithBaseURL(ts.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond}))
+	_, err := client.FileCacheUploadReader(context.Background(), "a.txt", io.MultiReader(strings.NewReader("x")), 1)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 for a non-seekable body", calls)
+	}
+}
@@ -0,0 +1,255 @@
+package zeptomail
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ZeptoMail request limits checked by Validate.
+const (
+	// MaxRecipients caps To, Cc and Bcc combined for a single send.
+	MaxRecipients = 50
+	// MaxBatchRecipients caps the To list of a batch send.
+	MaxBatchRecipients = 500
+	// MaxAttachmentBytes caps the decoded size of all inline attachments and
+	// images in one request.
+	MaxAttachmentBytes = 15 << 20
+)
+
+// Codes used in the ErrorDetail entries of a ValidationError.
+const (
+	ValidationRequired      = "REQUIRED"
+	ValidationInvalid       = "INVALID"
+	ValidationLimitExceeded = "LIMIT_EXCEEDED"
+)
+
+// ValidationError is returned when a request fails local validation. It
+// lists every problem found, each with the JSON path of the offending field
+// as Target, in the same shape as the Details of an APIError.
+type ValidationError struct {
+	Details []ErrorDetail
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Details))
+	for i, d := range e.Details {
+		msgs[i] = d.Target + ": " + d.Message
+	}
+	return "zeptomail: invalid request: " + strings.Join(msgs, "; ")
+}
+
+// Is makes a ValidationError match ErrInvalidRequest.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+// validator is implemented by request types that can check themselves
+// before being sent.
+type validator interface {
+	validate(batch bool) error
+}
+
+// Validate checks the request against the rules and limits of a single send:
+// a valid From address, at least one To recipient, a subject and a body, and
+// well-formed attachments, inline images and MIME headers.
+func (r *EmailRequest) Validate() error {
+	return r.validate(false)
+}
+
+// ValidateBatch is like Validate but applies the batch recipient limit.
+func (r *EmailRequest) ValidateBatch() error {
+	return r.validate(true)
+}
+
+func (r *EmailRequest) validate(batch bool) error {
+	v := &validation{}
+	v.address("from.address", r.From.Address)
+	v.recipients(r.To, r.Cc, r.Bcc, batch)
+	v.replyTo(r.ReplyTo)
+	if r.Subject == "" {
+		v.add(ValidationRequired, "subject", "subject is required")
+	}
+	if r.HTMLBody == "" && r.TextBody == "" {
+		v.add(ValidationRequired, "htmlbody", "htmlbody or textbody is required")
+	}
+	if r.BounceAddress != "" {
+		v.address("bounce_address", r.BounceAddress)
+	}
+	v.content(r.Attachments, r.InlineImages, r.HTMLBody)
+	v.headers(r.MimeHeaders)
+	return v.err()
+}
+
+// Validate checks the request against the rules and limits of a single
+// template send: a template key or alias, a valid From address, at least one
+// To recipient, and well-formed attachments, inline images and MIME headers.
+func (r *TemplateRequest) Validate() error {
+	return r.validate(false)
+}
+
+// ValidateBatch is like Validate but applies the batch recipient limit.
+func (r *TemplateRequest) ValidateBatch() error {
+	return r.validate(true)
+}
+
+func (r *TemplateRequest) validate(batch bool) error {
+	v := &validation{}
+	if r.TemplateKey == "" && r.TemplateAlias == "" {
+		v.add(ValidationRequired, "template_key", "template_key or template_alias is required")
+	}
+	v.address("from.address", r.From.Address)
+	v.recipients(r.To, r.Cc, r.Bcc, batch)
+	v.replyTo(r.ReplyTo)
+	if r.BounceAddress != "" {
+		v.address("bounce_address", r.BounceAddress)
+	}
+	v.content(r.Attachments, r.InlineImages, r.HTMLBody)
+	v.headers(r.MimeHeaders)
+	return v.err()
+}
+
+// validation collects the problems found while checking a request.
+type validation struct {
+	details []ErrorDetail
+}
+
+func (v *validation) add(code, target, format string, args ...interface{}) {
+	v.details = append(v.details, ErrorDetail{Code: code, Message: fmt.Sprintf(format, args...), Target: target})
+}
+
+func (v *validation) err() error {
+	if len(v.details) == 0 {
+		return nil
+	}
+	return &ValidationError{Details: v.details}
+}
+
+func (v *validation) address(target, address string) {
+	if address == "" {
+		v.add(ValidationRequired, target, "address is required")
+		return
+	}
+	if _, err := mail.ParseAddress(address); err != nil {
+		v.add(ValidationInvalid, target, "%q is not a valid email address", address)
+	}
+}
+
+func (v *validation) recipients(to, cc, bcc []Recipient, batch bool) {
+	if len(to) == 0 {
+		v.add(ValidationRequired, "to", "at least one recipient is required")
+	}
+	if batch {
+		if len(to) > MaxBatchRecipients {
+			v.add(ValidationLimitExceeded, "to", "%d recipients exceed the batch limit of %d", len(to), MaxBatchRecipients)
+		}
+	} else if n := len(to) + len(cc) + len(bcc); n > MaxRecipients {
+		v.add(ValidationLimitExceeded, "to", "%d recipients exceed the limit of %d", n, MaxRecipients)
+	}
+	for _, list := range []struct {
+		field      string
+		recipients []Recipient
+	}{{"to", to}, {"cc", cc}, {"bcc", bcc}} {
+		for i, r := range list.recipients {
+			v.address(fmt.Sprintf("%s[%d].email_address.address", list.field, i), r.Address)
+		}
+	}
+}
+
+func (v *validation) replyTo(addrs []EmailAddress) {
+	for i, a := range addrs {
+		v.address(fmt.Sprintf("reply_to[%d].address", i), a.Address)
+	}
+}
+
+var cidRef = regexp.MustCompile(`(?i)["'(]cid:([^"')\s]+)`)
+
+func (v *validation) content(attachments []Attachment, images []InlineImage, htmlBody string) {
+	total := 0
+	for i, a := range attachments {
+		target := fmt.Sprintf("attachments[%d]", i)
+		if a.FileCacheKey != "" {
+			continue
+		}
+		if a.Content == "" {
+			v.add(ValidationRequired, target+".content", "content or file_cache_key is required")
+			continue
+		}
+		if a.Name == "" {
+			v.add(ValidationRequired, target+".name", "name is required when content is set")
+		}
+		total += decodedLen(a.Content)
+	}
+
+	cids := make(map[string]bool, len(images))
+	for i, img := range images {
+		target := fmt.Sprintf("inline_images[%d]", i)
+		switch {
+		case img.CID == "":
+			v.add(ValidationRequired, target+".cid", "cid is required")
+		case cids[img.CID]:
+			v.add(ValidationInvalid, target+".cid", "duplicate cid %q", img.CID)
+		}
+		cids[img.CID] = true
+		if img.MimeType != "" && !isImageType(img.MimeType) {
+			v.add(ValidationInvalid, target+".mime_type", "%s is not an image type", img.MimeType)
+		}
+		if img.FileCacheKey == "" {
+			if img.Content == "" {
+				v.add(ValidationRequired, target+".content", "content or file_cache_key is required")
+			}
+			total += decodedLen(img.Content)
+		}
+	}
+
+	if total > MaxAttachmentBytes {
+		v.add(ValidationLimitExceeded, "attachments", "attachments total %d bytes, above the limit of %d", total, MaxAttachmentBytes)
+	}
+
+	for _, m := range cidRef.FindAllStringSubmatch(htmlBody, -1) {
+		if !cids[m[1]] {
+			v.add(ValidationInvalid, "htmlbody", "cid:%s has no matching inline image", m[1])
+		}
+	}
+}
+
+func (v *validation) headers(headers map[string]string) {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !validHeaderName(key) {
+			v.add(ValidationInvalid, "mime_headers", "%q is not a valid header name", key)
+		}
+	}
+}
+
+// validHeaderName reports whether name is a valid RFC 5322 field name:
+// printable ASCII other than space and colon.
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c < 33 || c > 126 || c == ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// decodedLen returns the number of bytes the base64 string s decodes to.
+func decodedLen(s string) int {
+	n := len(s) / 4 * 3
+	switch {
+	case strings.HasSuffix(s, "=="):
+		n -= 2
+	case strings.HasSuffix(s, "="):
+		n--
+	}
+	return n
+}
@@ -0,0 +1,190 @@
+package zeptomail
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func detailTargets(t *testing.T, err error) []string {
+	t.Helper()
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+	}
+	targets := make([]string, len(vErr.Details))
+	for i, d := range vErr.Details {
+		targets[i] = d.Target
+	}
+	return targets
+}
+
+func hasTarget(targets []string, want string) bool {
+	for _, t := range targets {
+		if t == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEmailRequest_Validate_Valid(t *testing.T) {
+	if err := testEmailRequest().Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestEmailRequest_Validate_Empty(t *testing.T) {
+	err := (&EmailRequest{}).Validate()
+	targets := detailTargets(t, err)
+	for _, want := range []string{"from.address", "to", "subject", "htmlbody"} {
+		if !hasTarget(targets, want) {
+			t.Errorf("targets = %v, want %q", targets, want)
+		}
+	}
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Error("ValidationError should match ErrInvalidRequest")
+	}
+}
+
+func TestEmailRequest_Validate_BadAddresses(t *testing.T) {
+	req := testEmailRequest()
+	req.Cc = []Recipient{{EmailAddress: EmailAddress{Address: "not an address"}}}
+	req.ReplyTo = []EmailAddress{{Address: "@nope"}}
+	targets := detailTargets(t, req.Validate())
+	if !hasTarget(targets, "cc[0].email_address.address") || !hasTarget(targets, "reply_to[0].address") {
+		t.Errorf("targets = %v", targets)
+	}
+}
+
+func TestEmailRequest_Validate_RecipientLimits(t *testing.T) {
+	req := testEmailRequest()
+	for i := 0; i < MaxRecipients; i++ {
+		req.Bcc = append(req.Bcc, Recipient{EmailAddress: EmailAddress{Address: "x@example.com"}})
+	}
+	if err := req.Validate(); err == nil || !strings.Contains(err.Error(), "exceed the limit") {
+		t.Errorf("Validate() = %v, want recipient limit error", err)
+	}
+
+	batch := testEmailRequest()
+	batch.To = make([]Recipient, MaxBatchRecipients+1)
+	for i := range batch.To {
+		batch.To[i].Address = "x@example.com"
+	}
+	if err := batch.ValidateBatch(); err == nil || !strings.Contains(err.Error(), "batch limit") {
+		t.Errorf("ValidateBatch() = %v, want batch limit error", err)
+	}
+	batch.To = batch.To[:MaxBatchRecipients]
+	if err := batch.ValidateBatch(); err != nil {
+		t.Errorf("ValidateBatch() = %v, want nil at the limit", err)
+	}
+}
+
+func TestEmailRequest_Validate_Attachments(t *testing.T) {
+	req := testEmailRequest()
+	req.Attachments = []Attachment{
+		{FileCacheKey: "fck"},
+		{Content: "SGVsbG8=", MimeType: "text/plain"},
+		{Name: "empty.txt"},
+	}
+	targets := detailTargets(t, req.Validate())
+	if !hasTarget(targets, "attachments[1].name") || !hasTarget(targets, "attachments[2].content") {
+		t.Errorf("targets = %v", targets)
+	}
+
+	req.Attachments = []Attachment{{Name: "big.bin", Content: strings.Repeat("AAAA", MaxAttachmentBytes/3+1)}}
+	if !hasTarget(detailTargets(t, req.Validate()), "attachments") {
+		t.Error("want total size error")
+	}
+}
+
+func TestEmailRequest_Validate_InlineImages(t *testing.T) {
+	req := testEmailRequest()
+	req.HTMLBody = `<img src="cid:logo"><img src='cid:missing'>`
+	req.InlineImages = []InlineImage{
+		{CID: "logo", Content: "iVBORw0KGgo="},
+		{CID: "logo", Content: "iVBORw0KGgo="},
+		{CID: "doc", Content: "dGV4dA==", MimeType: "text/plain"},
+	}
+	err := req.Validate()
+	targets := detailTargets(t, err)
+	if !hasTarget(targets, "inline_images[1].cid") {
+		t.Errorf("targets = %v, want duplicate cid", targets)
+	}
+	if !hasTarget(targets, "inline_images[2].mime_type") {
+		t.Errorf("targets = %v, want non-image type", targets)
+	}
+	if !strings.Contains(err.Error(), "cid:missing has no matching inline image") {
+		t.Errorf("err = %v, want missing cid reference", err)
+	}
+	if strings.Contains(err.Error(), "cid:logo has no") {
+		t.Errorf("err = %v, logo is defined", err)
+	}
+}
+
+func TestEmailRequest_Validate_MimeHeaders(t *testing.T) {
+	req := testEmailRequest()
+	req.MimeHeaders = map[string]string{"X-Good": "1", "Bad Header": "2", "Also:Bad": "3"}
+	err := req.Validate()
+	if !strings.Contains(err.Error(), `"Bad Header"`) || !strings.Contains(err.Error(), `"Also:Bad"`) {
+		t.Errorf("err = %v", err)
+	}
+	if strings.Contains(err.Error(), "X-Good") {
+		t.Errorf("err = %v, X-Good is valid", err)
+	}
+}
+
+func TestTemplateRequest_Validate(t *testing.T) {
+	req := &TemplateRequest{
+		From: EmailAddress{Address: "a@b.com"},
+		To:   []Recipient{{EmailAddress: EmailAddress{Address: "c@d.com"}}},
+	}
+	if !hasTarget(detailTargets(t, req.Validate()), "template_key") {
+		t.Error("want template_key error")
+	}
+	req.TemplateAlias = "welcome"
+	if err := req.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestSendEmail_ValidatesLocally(t *testing.T) {
+	called := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(200)
+		w.Write([]byte(successJSON()))
+	}))
+	defer ts.Close()
+
+	client := newTestEmailClient(ts.URL)
+	_, err := client.SendEmail(context.Background(), &EmailRequest{})
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+	}
+	if called {
+		t.Error("invalid request should not reach the API")
+	}
+}
+
+func TestSendEmail_WithValidationDisabled(t *testing.T) {
+	called := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(200)
+		w.Write([]byte(successJSON()))
+	}))
+	defer ts.Close()
+
+	client := NewEmailClient("key", WithBaseURL(ts.URL), WithValidation(false))
+	if _, err := client.SendEmail(context.Background(), &EmailRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("request should reach the API when validation is disabled")
+	}
+}
@@ -0,0 +1,275 @@
+// Package webhooks receives ZeptoMail webhook notifications.
+//
+// ZeptoMail posts a JSON payload to your endpoint whenever a mail bounces, is
+// opened, has a link clicked or is reported as spam. Parse turns a payload
+// into typed events and Handler serves them over HTTP, dispatching each event
+// to the callbacks registered for its type:
+//
+//	h := webhooks.NewHandler()
+//	h.OnBounce(func(ctx context.Context, e *webhooks.BounceEvent) error {
+//		return suppress(ctx, e.Recipient, e.Hard)
+//	})
+//	http.Handle("/zeptomail/events", h)
+//
+// Put a Verifier in front of the Handler so that only requests carrying the
+// configured secret or a valid signature get through:
+//
+//	v := &webhooks.Verifier{SigningKey: []byte(os.Getenv("ZEPTOMAIL_WEBHOOK_KEY"))}
+//	http.Handle("/zeptomail/events", v.Middleware(h))
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go"
+)
+
+// EventType is the event name ZeptoMail reports in a webhook payload.
+type EventType string
+
+// Event types sent by ZeptoMail.
+const (
+	EventHardBounce   EventType = "hardbounce"
+	EventSoftBounce   EventType = "softbounce"
+	EventOpen         EventType = "email_open"
+	EventClick        EventType = "email_link_click"
+	EventFeedbackLoop EventType = "feedback_loop"
+)
+
+// ErrMalformed is returned by Parse for bodies that are not a ZeptoMail
+// webhook payload.
+var ErrMalformed = errors.New("webhooks: malformed payload")
+
+// Event is implemented by every event type. Use a type switch to get at the
+// concrete *BounceEvent, *OpenEvent, *ClickEvent, *FeedbackLoopEvent or
+// *UnknownEvent.
+type Event interface {
+	EventType() EventType
+	EventMeta() *Meta
+}
+
+// Meta holds what every event carries about the webhook delivery and the
+// original send.
+type Meta struct {
+	Type EventType
+	// RequestID is the request_id returned when the mail was sent.
+	RequestID string
+	// ClientReference is the client_reference set on the send request.
+	ClientReference string
+	// WebhookRequestID identifies the webhook delivery; redeliveries reuse
+	// it, so it can be used to drop duplicates.
+	WebhookRequestID string
+	MailAgentKey     string
+	Email            EmailInfo
+	// Time is when the event happened. It is zero if ZeptoMail sent no
+	// time or one that could not be parsed.
+	Time time.Time
+	// Raw is the event's detail object as sent, for fields not modelled
+	// by the typed events.
+	Raw json.RawMessage
+}
+
+// EventType returns m.Type.
+func (m *Meta) EventType() EventType { return m.Type }
+
+// EventMeta returns m itself, so that the event types embedding Meta
+// implement Event.
+func (m *Meta) EventMeta() *Meta { return m }
+
+// EmailInfo describes the mail an event is about.
+type EmailInfo struct {
+	EmailReference  string                   `json:"email_reference"`
+	ClientReference string                   `json:"client_reference"`
+	Subject         string                   `json:"subject"`
+	BounceAddress   string                   `json:"bounce_address"`
+	From            zeptomail.EmailAddress   `json:"from"`
+	To              []zeptomail.Recipient    `json:"to"`
+	Cc              []zeptomail.Recipient    `json:"cc"`
+	Bcc             []zeptomail.Recipient    `json:"bcc"`
+	ReplyTo         []zeptomail.EmailAddress `json:"reply_to"`
+	IsSMTPTrigger   bool                     `json:"is_smtp_trigger"`
+	ProcessedTime   string                   `json:"processed_time"`
+}
+
+// BounceEvent reports a hard or soft bounce.
+type BounceEvent struct {
+	Meta
+	// Hard is true for hard bounces, which ZeptoMail will not retry.
+	Hard              bool
+	Recipient         string
+	Reason            string
+	DiagnosticMessage string
+}
+
+// Client describes the mail client, browser or device behind an open or a
+// click, as far as ZeptoMail could tell.
+type Client struct {
+	IPAddress   string
+	UserAgent   string
+	EmailClient string
+	Device      string
+}
+
+// OpenEvent reports that a recipient opened the mail.
+type OpenEvent struct {
+	Meta
+	Client
+}
+
+// ClickEvent reports that a recipient clicked a tracked link.
+type ClickEvent struct {
+	Meta
+	Client
+	Link string
+}
+
+// FeedbackLoopEvent reports that a recipient marked the mail as spam.
+type FeedbackLoopEvent struct {
+	Meta
+	Recipient string
+}
+
+// UnknownEvent is an event of a type this package does not model yet.
+type UnknownEvent struct {
+	Meta
+}
+
+// payload is the JSON body of a webhook request.
+type payload struct {
+	EventName        []string  `json:"event_name"`
+	EventMessage     []message `json:"event_message"`
+	MailAgentKey     string    `json:"mailagent_key"`
+	WebhookRequestID string    `json:"webhook_request_id"`
+}
+
+type message struct {
+	RequestID string      `json:"request_id"`
+	EmailInfo EmailInfo   `json:"email_info"`
+	EventData []eventData `json:"event_data"`
+}
+
+type eventData struct {
+	Object  string            `json:"object"`
+	Details []json.RawMessage `json:"details"`
+}
+
+type eventDetail struct {
+	Time              string `json:"time"`
+	Reason            string `json:"reason"`
+	DiagnosticMessage string `json:"diagnostic_message"`
+	BouncedRecipient  string `json:"bounced_recipient"`
+	Recipient         string `json:"recipient"`
+	ClickedLink       string `json:"clicked_link"`
+	IPAddress         string `json:"ip_address"`
+	UserAgent         string `json:"user_agent"`
+	EmailClient       struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"email_client"`
+	Device struct {
+		Name string `json:"name"`
+	} `json:"device"`
+}
+
+// Parse decodes a webhook payload into one event per reported detail, in
+// the order sent.
+func Parse(body []byte) ([]Event, error) {
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if len(p.EventMessage) == 0 {
+		return nil, fmt.Errorf("%w: no event_message", ErrMalformed)
+	}
+
+	var events []Event
+	for _, msg := range p.EventMessage {
+		for _, data := range msg.EventData {
+			typ := EventType(data.Object)
+			if typ == "" && len(p.EventName) == 1 {
+				typ = EventType(p.EventName[0])
+			}
+			if typ == "" {
+				return nil, fmt.Errorf("%w: event without a type", ErrMalformed)
+			}
+			for _, raw := range data.Details {
+				var d eventDetail
+				if err := json.Unmarshal(raw, &d); err != nil {
+					return nil, fmt.Errorf("%w: %s details: %v", ErrMalformed, typ, err)
+				}
+				meta := Meta{
+					Type:             typ,
+					RequestID:        msg.RequestID,
+					ClientReference:  msg.EmailInfo.ClientReference,
+					WebhookRequestID: p.WebhookRequestID,
+					MailAgentKey:     p.MailAgentKey,
+					Email:            msg.EmailInfo,
+					Time:             parseTime(d.Time),
+					Raw:              raw,
+				}
+				events = append(events, newEvent(meta, &d))
+			}
+		}
+	}
+	return events, nil
+}
+
+func newEvent(meta Meta, d *eventDetail) Event {
+	client := Client{
+		IPAddress:   d.IPAddress,
+		UserAgent:   d.UserAgent,
+		EmailClient: d.EmailClient.Name,
+		Device:      d.Device.Name,
+	}
+	switch meta.Type {
+	case EventHardBounce, EventSoftBounce:
+		return &BounceEvent{
+			Meta:              meta,
+			Hard:              meta.Type == EventHardBounce,
+			Recipient:         firstNonEmpty(d.BouncedRecipient, d.Recipient),
+			Reason:            d.Reason,
+			DiagnosticMessage: d.DiagnosticMessage,
+		}
+	case EventOpen:
+		return &OpenEvent{Meta: meta, Client: client}
+	case EventClick:
+		return &ClickEvent{Meta: meta, Client: client, Link: d.ClickedLink}
+	case EventFeedbackLoop:
+		recipient := d.Recipient
+		if recipient == "" && len(meta.Email.To) == 1 {
+			recipient = meta.Email.To[0].Address
+		}
+		return &FeedbackLoopEvent{Meta: meta, Recipient: recipient}
+	default:
+		return &UnknownEvent{Meta: meta}
+	}
+}
+
+// timeLayouts are the formats seen in webhook payloads, with and without
+// milliseconds and a colon in the zone offset.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000-0700",
+	"2006-01-02T15:04:05-0700",
+}
+
+func parseTime(s string) time.Time {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
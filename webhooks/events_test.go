@@ -0,0 +1,108 @@
+package webhooks
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func parseFixture(t *testing.T, name string) []Event {
+	t.Helper()
+	body, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := Parse(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestParse_Bounce(t *testing.T) {
+	events := parseFixture(t, "hardbounce.json")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	e, ok := events[0].(*BounceEvent)
+	if !ok {
+		t.Fatalf("got %T, want *BounceEvent", events[0])
+	}
+	if !e.Hard || e.EventType() != EventHardBounce {
+		t.Errorf("Hard = %v, type = %q", e.Hard, e.EventType())
+	}
+	if e.Recipient != "nobody@example.org" || e.DiagnosticMessage != "550 5.1.1 User unknown" || e.Reason == "" {
+		t.Errorf("unexpected bounce details: %+v", e)
+	}
+	if e.ClientReference != "order-1234" || e.RequestID != "2d6f.117fe6ec4fda4841.m1.8cbd6a70-5dd7-11ec-9a1c-525400fa05f6" {
+		t.Errorf("ClientReference = %q, RequestID = %q", e.ClientReference, e.RequestID)
+	}
+	if e.WebhookRequestID != "wh-0001" || e.MailAgentKey != "2d6f.117fe6ec4fda4841" {
+		t.Errorf("WebhookRequestID = %q, MailAgentKey = %q", e.WebhookRequestID, e.MailAgentKey)
+	}
+	if e.Email.Subject != "Your order has shipped" || e.Email.From.Address != "orders@example.com" || e.Email.To[0].Address != "nobody@example.org" {
+		t.Errorf("unexpected email info: %+v", e.Email)
+	}
+	want := time.Date(2021, 12, 16, 6, 57, 25, 823e6, time.UTC)
+	if !e.Time.Equal(want) {
+		t.Errorf("Time = %v, want %v", e.Time, want)
+	}
+}
+
+func TestParse_OpenAndClick(t *testing.T) {
+	events := parseFixture(t, "open_click.json")
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	open, ok := events[0].(*OpenEvent)
+	if !ok {
+		t.Fatalf("got %T, want *OpenEvent", events[0])
+	}
+	if open.EmailClient != "Gmail" || open.Device != "Desktop" || open.IPAddress != "203.0.113.7" || open.Time.IsZero() {
+		t.Errorf("unexpected open: %+v", open)
+	}
+	click, ok := events[1].(*ClickEvent)
+	if !ok {
+		t.Fatalf("got %T, want *ClickEvent", events[1])
+	}
+	if click.Link != "https://example.com/offer" || click.ClientReference != "newsletter-42" || click.Time.IsZero() {
+		t.Errorf("unexpected click: %+v", click)
+	}
+}
+
+func TestParse_FeedbackLoopAndUnknown(t *testing.T) {
+	events := parseFixture(t, "feedback_loop.json")
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	fbl, ok := events[0].(*FeedbackLoopEvent)
+	if !ok {
+		t.Fatalf("got %T, want *FeedbackLoopEvent", events[0])
+	}
+	if fbl.Recipient != "annoyed@example.org" {
+		t.Errorf("Recipient = %q", fbl.Recipient)
+	}
+	unknown, ok := events[1].(*UnknownEvent)
+	if !ok {
+		t.Fatalf("got %T, want *UnknownEvent", events[1])
+	}
+	if unknown.Type != "delivered_later" || string(unknown.Raw) == "" {
+		t.Errorf("unexpected unknown event: %+v", unknown)
+	}
+}
+
+func TestParse_Malformed(t *testing.T) {
+	for _, body := range []string{
+		``,
+		`not json`,
+		`[]`,
+		`{}`,
+		`{"event_message": [{"event_data": [{"details": [{}]}]}]}`,
+		`{"event_name": ["hardbounce"], "event_message": [{"event_data": [{"object": "hardbounce", "details": ["x"]}]}]}`,
+	} {
+		if _, err := Parse([]byte(body)); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Parse(%q) = %v, want ErrMalformed", body, err)
+		}
+	}
+}
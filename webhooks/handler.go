@@ -0,0 +1,159 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxBodyBytes caps the size of a webhook body unless overridden with
+// WithMaxBodyBytes. ZeptoMail payloads are a few kilobytes.
+const DefaultMaxBodyBytes = 1 << 20
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithMaxBodyBytes changes the largest body the Handler accepts. Larger
+// bodies are rejected with 413 Request Entity Too Large.
+func WithMaxBodyBytes(n int64) Option {
+	return func(h *Handler) {
+		h.maxBodyBytes = n
+	}
+}
+
+// WithErrorHandler is called whenever a request is rejected or a callback
+// fails, e.g. for logging. It does not change the response.
+func WithErrorHandler(fn func(r *http.Request, err error)) Option {
+	return func(h *Handler) {
+		h.onError = fn
+	}
+}
+
+// Handler is an http.Handler that parses webhook requests and dispatches
+// their events to the registered callbacks. Register callbacks before
+// serving requests.
+//
+// It answers 405 for methods other than POST, 413 for bodies over the size
+// limit, 400 for malformed payloads and 500 when a callback returns an
+// error, so that ZeptoMail delivers the webhook again. Callbacks should
+// therefore be idempotent; Meta.WebhookRequestID identifies redeliveries.
+type Handler struct {
+	maxBodyBytes int64
+	onError      func(r *http.Request, err error)
+
+	onBounce       []func(context.Context, *BounceEvent) error
+	onOpen         []func(context.Context, *OpenEvent) error
+	onClick        []func(context.Context, *ClickEvent) error
+	onFeedbackLoop []func(context.Context, *FeedbackLoopEvent) error
+	onEvent        []func(context.Context, Event) error
+}
+
+// NewHandler returns a Handler without callbacks.
+func NewHandler(opts ...Option) *Handler {
+	h := &Handler{maxBodyBytes: DefaultMaxBodyBytes}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// OnBounce registers fn for hard and soft bounces.
+func (h *Handler) OnBounce(fn func(context.Context, *BounceEvent) error) *Handler {
+	h.onBounce = append(h.onBounce, fn)
+	return h
+}
+
+// OnOpen registers fn for opens.
+func (h *Handler) OnOpen(fn func(context.Context, *OpenEvent) error) *Handler {
+	h.onOpen = append(h.onOpen, fn)
+	return h
+}
+
+// OnClick registers fn for link clicks.
+func (h *Handler) OnClick(fn func(context.Context, *ClickEvent) error) *Handler {
+	h.onClick = append(h.onClick, fn)
+	return h
+}
+
+// OnFeedbackLoop registers fn for spam complaints.
+func (h *Handler) OnFeedbackLoop(fn func(context.Context, *FeedbackLoopEvent) error) *Handler {
+	h.onFeedbackLoop = append(h.onFeedbackLoop, fn)
+	return h
+}
+
+// OnEvent registers fn for every event, including types without a typed
+// callback. It runs after the typed callbacks.
+func (h *Handler) OnEvent(fn func(context.Context, Event) error) *Handler {
+	h.onEvent = append(h.onEvent, fn)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.fail(w, r, http.StatusMethodNotAllowed, errors.New("webhooks: method not allowed"))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodyBytes))
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		h.fail(w, r, status, err)
+		return
+	}
+
+	events, err := Parse(body)
+	if err != nil {
+		h.fail(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, e := range events {
+		if err := h.Dispatch(r.Context(), e); err != nil {
+			h.fail(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Dispatch runs the callbacks registered for e, stopping at the first
+// error. ServeHTTP calls it for every parsed event.
+func (h *Handler) Dispatch(ctx context.Context, e Event) error {
+	var err error
+	switch e := e.(type) {
+	case *BounceEvent:
+		err = run(ctx, h.onBounce, e)
+	case *OpenEvent:
+		err = run(ctx, h.onOpen, e)
+	case *ClickEvent:
+		err = run(ctx, h.onClick, e)
+	case *FeedbackLoopEvent:
+		err = run(ctx, h.onFeedbackLoop, e)
+	}
+	if err != nil {
+		return err
+	}
+	return run(ctx, h.onEvent, e)
+}
+
+func run[E any](ctx context.Context, fns []func(context.Context, E) error, e E) error {
+	for _, fn := range fns {
+		if err := fn(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if h.onError != nil {
+		h.onError(r, err)
+	}
+	http.Error(w, http.StatusText(status), status)
+}
@@ -0,0 +1,111 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func post(t *testing.T, h http.Handler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/webhook", strings.NewReader(body)))
+	return rec
+}
+
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestHandler_Dispatch(t *testing.T) {
+	var got []string
+	h := NewHandler().
+		OnBounce(func(ctx context.Context, e *BounceEvent) error {
+			got = append(got, "bounce:"+e.Recipient)
+			return nil
+		}).
+		OnOpen(func(ctx context.Context, e *OpenEvent) error {
+			got = append(got, "open:"+e.EmailClient)
+			return nil
+		}).
+		OnClick(func(ctx context.Context, e *ClickEvent) error {
+			got = append(got, "click:"+e.Link)
+			return nil
+		}).
+		OnFeedbackLoop(func(ctx context.Context, e *FeedbackLoopEvent) error {
+			got = append(got, "fbl:"+e.Recipient)
+			return nil
+		}).
+		OnEvent(func(ctx context.Context, e Event) error {
+			got = append(got, "event:"+string(e.EventType()))
+			return nil
+		})
+
+	for _, name := range []string{"hardbounce.json", "open_click.json", "feedback_loop.json"} {
+		if rec := post(t, h, fixture(t, name)); rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", name, rec.Code)
+		}
+	}
+
+	want := []string{
+		"bounce:nobody@example.org", "event:hardbounce",
+		"open:Gmail", "event:email_open",
+		"click:https://example.com/offer", "event:email_link_click",
+		"fbl:annoyed@example.org", "event:feedback_loop",
+		"event:delivered_later",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("dispatched\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestHandler_Rejects(t *testing.T) {
+	var errs []error
+	h := NewHandler(WithMaxBodyBytes(64), WithErrorHandler(func(r *http.Request, err error) {
+		errs = append(errs, err)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/webhook", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "POST" {
+		t.Errorf("GET: status %d, Allow %q", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	if rec := post(t, h, `{"event_message": "`+strings.Repeat("x", 100)+`"}`); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body: status %d", rec.Code)
+	}
+
+	if rec := post(t, h, `{"oops"`); rec.Code != http.StatusBadRequest {
+		t.Errorf("malformed body: status %d", rec.Code)
+	}
+	if len(errs) != 3 || !errors.Is(errs[2], ErrMalformed) {
+		t.Errorf("error handler got %v", errs)
+	}
+}
+
+func TestHandler_CallbackError(t *testing.T) {
+	calls := 0
+	h := NewHandler().
+		OnBounce(func(ctx context.Context, e *BounceEvent) error {
+			return errors.New("database down")
+		}).
+		OnEvent(func(ctx context.Context, e Event) error {
+			calls++
+			return nil
+		})
+	if rec := post(t, h, fixture(t, "hardbounce.json")); rec.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500 so that ZeptoMail redelivers", rec.Code)
+	}
+	if calls != 0 {
+		t.Error("OnEvent ran after a failed typed callback")
+	}
+}
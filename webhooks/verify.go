@@ -0,0 +1,222 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSignatureHeader carries the HMAC signature of a webhook body
+// unless Verifier.SignatureHeader names another header.
+const DefaultSignatureHeader = "X-Webhook-Signature"
+
+// DefaultTolerance is how far a signature timestamp may be from the current
+// time unless Verifier.Tolerance says otherwise.
+const DefaultTolerance = 5 * time.Minute
+
+// Errors reported by Verifier.Verify. They all lead to 401 Unauthorized in
+// Verifier.Middleware.
+var (
+	ErrUnauthenticated  = errors.New("webhooks: missing or wrong authorization header")
+	ErrInvalidSignature = errors.New("webhooks: invalid signature")
+	ErrStaleTimestamp   = errors.New("webhooks: signature timestamp outside tolerance")
+)
+
+// Verifier authenticates webhook requests with a shared secret: a fixed
+// request header, an HMAC signature of the body, or both. At least one of
+// AuthHeader or SigningKey must be set.
+//
+// The signature scheme is this package's own, not a documented ZeptoMail
+// format. Use it where you control the signer, such as a proxy or queue
+// that forwards webhooks to your service after signing them with Sign.
+// A signature header has the form
+//
+//	ts=<unix time>;s=<signature>;s-algorithm=HmacSHA256
+//
+// where the timestamp is in seconds or milliseconds and the signature is the
+// base64 or hex HMAC-SHA256 of the timestamp, a dot and the raw body, keyed
+// with SigningKey.
+type Verifier struct {
+	// AuthHeader and AuthValue configure a secret that every request must
+	// carry in a header, such as "Authorization: Bearer <secret>".
+	AuthHeader string
+	AuthValue  string
+
+	// SigningKey enables HMAC verification of the body.
+	SigningKey []byte
+	// SignatureHeader defaults to DefaultSignatureHeader.
+	SignatureHeader string
+	// Tolerance bounds the age of a signature to prevent replays. It
+	// defaults to DefaultTolerance; a negative value disables the check.
+	Tolerance time.Duration
+
+	// MaxBodyBytes caps the body read by Middleware. It defaults to
+	// DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+	// OnError is called with the reason whenever Middleware rejects a
+	// request.
+	OnError func(r *http.Request, err error)
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// Verify checks the headers of r against body, which must be the request
+// body exactly as received.
+func (v *Verifier) Verify(r *http.Request, body []byte) error {
+	if v.AuthHeader == "" && len(v.SigningKey) == 0 {
+		return errors.New("webhooks: Verifier has neither AuthHeader nor SigningKey")
+	}
+	if v.AuthHeader != "" {
+		got := r.Header.Get(v.AuthHeader)
+		if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(v.AuthValue)) != 1 {
+			return ErrUnauthenticated
+		}
+	}
+	if len(v.SigningKey) > 0 {
+		return v.verifySignature(r.Header.Get(v.signatureHeader()), body)
+	}
+	return nil
+}
+
+func (v *Verifier) verifySignature(header string, body []byte) error {
+	var ts, sig, alg string
+	for _, part := range strings.Split(header, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "ts":
+			ts = value
+		case "s":
+			sig = value
+		case "s-algorithm":
+			alg = value
+		}
+	}
+	if ts == "" || sig == "" {
+		return fmt.Errorf("%w: missing timestamp or signature", ErrInvalidSignature)
+	}
+	if alg != "" && !strings.EqualFold(alg, "HmacSHA256") {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, alg)
+	}
+
+	got, err := decodeSignature(sig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if !hmac.Equal(got, signature(v.SigningKey, ts, body)) {
+		return ErrInvalidSignature
+	}
+
+	// The timestamp is only trusted once the signature covering it checks out.
+	at, err := parseTimestamp(ts)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	tolerance := v.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	if tolerance > 0 {
+		if d := v.now().Sub(at); d > tolerance || d < -tolerance {
+			return ErrStaleTimestamp
+		}
+	}
+	return nil
+}
+
+// Middleware rejects requests that fail Verify with 401 Unauthorized and
+// passes the others, body intact, to next.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := v.MaxBodyBytes
+		if limit <= 0 {
+			limit = DefaultMaxBodyBytes
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+		if err != nil {
+			status := http.StatusBadRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			v.fail(w, r, status, err)
+			return
+		}
+		if err := v.Verify(r, body); err != nil {
+			v.fail(w, r, http.StatusUnauthorized, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Sign returns a signature header value for body at time t, in the format
+// Verifier checks. Senders set it as DefaultSignatureHeader, or the header
+// named by Verifier.SignatureHeader.
+func Sign(key []byte, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.UnixMilli(), 10)
+	return "ts=" + ts + ";s=" + base64.StdEncoding.EncodeToString(signature(key, ts, body)) + ";s-algorithm=HmacSHA256"
+}
+
+func signature(key []byte, ts string, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func decodeSignature(s string) ([]byte, error) {
+	if len(s) == hex.EncodedLen(sha256.Size) {
+		if b, err := hex.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// parseTimestamp accepts Unix time in seconds or milliseconds.
+func parseTimestamp(ts string) (time.Time, error) {
+	n, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad timestamp %q", ts)
+	}
+	if n > 1e12 {
+		return time.UnixMilli(n), nil
+	}
+	return time.Unix(n, 0), nil
+}
+
+func (v *Verifier) signatureHeader() string {
+	if v.SignatureHeader != "" {
+		return v.SignatureHeader
+	}
+	return DefaultSignatureHeader
+}
+
+func (v *Verifier) now() time.Time {
+	if v.Now != nil {
+		return v.Now()
+	}
+	return time.Now()
+}
+
+func (v *Verifier) fail(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if v.OnError != nil {
+		v.OnError(r, err)
+	}
+	http.Error(w, http.StatusText(status), status)
+}
@@ -0,0 +1,134 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	fixtureKey = []byte("whsec-test-key")
+	// fixtureTime is the moment the signatures below were made.
+	fixtureTime = time.UnixMilli(1639637845823)
+)
+
+// Signatures of testdata/hardbounce.json made with fixtureKey, computed
+// independently of this package.
+const (
+	signedBase64 = "ts=1639637845823;s=fBs3p5Jg5DYNMfT1tRvMSinGHvZYUrrXCvVo88X+gXY=;s-algorithm=HmacSHA256"
+	signedHex    = "ts=1639637845823;s=7c1b37a79260e4360d31f4f5b51bcc4a29c61ef65852bad70af568f3c5fe8176"
+	signedSecs   = "ts=1639637845;s=0f0f1e1329ef8bb1a63d90a32609aadab388f73d22020b791c293519845ba175;s-algorithm=HmacSHA256"
+)
+
+func signedRequest(body, signature string) *http.Request {
+	r := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+	if signature != "" {
+		r.Header.Set(DefaultSignatureHeader, signature)
+	}
+	return r
+}
+
+func TestVerifier_SignedFixtures(t *testing.T) {
+	body := fixture(t, "hardbounce.json")
+	v := &Verifier{SigningKey: fixtureKey, Now: func() time.Time { return fixtureTime.Add(time.Minute) }}
+
+	for _, sig := range []string{signedBase64, signedHex, signedSecs, Sign(fixtureKey, fixtureTime, []byte(body))} {
+		if err := v.Verify(signedRequest(body, sig), []byte(body)); err != nil {
+			t.Errorf("Verify(%q) = %v", sig, err)
+		}
+	}
+	if got := Sign(fixtureKey, fixtureTime, []byte(body)); got != signedBase64 {
+		t.Errorf("Sign = %q, want %q", got, signedBase64)
+	}
+}
+
+func TestVerifier_Rejects(t *testing.T) {
+	body := fixture(t, "hardbounce.json")
+	now := func() time.Time { return fixtureTime }
+	tests := []struct {
+		name string
+		v    *Verifier
+		body string
+		sig  string
+		want error
+	}{
+		{"tampered body", &Verifier{SigningKey: fixtureKey, Now: now}, strings.Replace(body, "nobody", "victim", 1), signedBase64, ErrInvalidSignature},
+		{"wrong key", &Verifier{SigningKey: []byte("other"), Now: now}, body, signedBase64, ErrInvalidSignature},
+		{"missing header", &Verifier{SigningKey: fixtureKey, Now: now}, body, "", ErrInvalidSignature},
+		{"garbage header", &Verifier{SigningKey: fixtureKey, Now: now}, body, "ts=1;s=!!!", ErrInvalidSignature},
+		{"other algorithm", &Verifier{SigningKey: fixtureKey, Now: now}, body, strings.Replace(signedBase64, "HmacSHA256", "HmacSHA1", 1), ErrInvalidSignature},
+		{"shifted timestamp", &Verifier{SigningKey: fixtureKey, Now: now}, body, strings.Replace(signedBase64, "ts=1639637845823", "ts=1639637945823", 1), ErrInvalidSignature},
+		{"replayed", &Verifier{SigningKey: fixtureKey, Now: func() time.Time { return fixtureTime.Add(time.Hour) }}, body, signedBase64, ErrStaleTimestamp},
+		{"from the future", &Verifier{SigningKey: fixtureKey, Now: func() time.Time { return fixtureTime.Add(-10 * time.Minute) }}, body, signedBase64, ErrStaleTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.v.Verify(signedRequest(tt.body, tt.sig), []byte(tt.body)); !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// A negative tolerance accepts old signatures.
+	v := &Verifier{SigningKey: fixtureKey, Tolerance: -1}
+	if err := v.Verify(signedRequest(body, signedBase64), []byte(body)); err != nil {
+		t.Errorf("Verify without tolerance = %v", err)
+	}
+}
+
+func TestVerifier_AuthHeader(t *testing.T) {
+	v := &Verifier{AuthHeader: "Authorization", AuthValue: "Bearer s3cret"}
+	r := httptest.NewRequest("POST", "/webhook", nil)
+	if err := v.Verify(r, nil); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("missing header: %v", err)
+	}
+	r.Header.Set("Authorization", "Bearer s3cre")
+	if err := v.Verify(r, nil); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("wrong header: %v", err)
+	}
+	r.Header.Set("Authorization", "Bearer s3cret")
+	if err := v.Verify(r, nil); err != nil {
+		t.Errorf("right header: %v", err)
+	}
+
+	if err := (&Verifier{}).Verify(r, nil); err == nil {
+		t.Error("an unconfigured Verifier accepted the request")
+	}
+}
+
+func TestVerifier_Middleware(t *testing.T) {
+	body := fixture(t, "hardbounce.json")
+	var errs []error
+	v := &Verifier{
+		AuthHeader: "X-Webhook-Token",
+		AuthValue:  "token",
+		SigningKey: fixtureKey,
+		Now:        func() time.Time { return fixtureTime },
+		OnError:    func(r *http.Request, err error) { errs = append(errs, err) },
+	}
+
+	var bounces int
+	h := v.Middleware(NewHandler().OnBounce(func(ctx context.Context, e *BounceEvent) error {
+		bounces++
+		return nil
+	}))
+
+	r := signedRequest(body, signedBase64)
+	r.Header.Set("X-Webhook-Token", "token")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK || bounces != 1 {
+		t.Errorf("verified request: status %d, %d bounces", rec.Code, bounces)
+	}
+
+	r = signedRequest(body, signedBase64)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusUnauthorized || bounces != 1 || len(errs) != 1 || !errors.Is(errs[0], ErrUnauthenticated) {
+		t.Errorf("unauthenticated request: status %d, %d bounces, errors %v", rec.Code, bounces, errs)
+	}
+}
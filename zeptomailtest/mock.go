@@ -0,0 +1,192 @@
+package zeptomailtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/navnitms/zeptomail-sdk-go"
+)
+
+// MockCall is a method call recorded by MockSender or MockTemplateManager.
+type MockCall struct {
+	// Method is the interface method name, e.g. "SendEmail".
+	Method string
+	// Args holds the arguments after the context, in order.
+	Args []interface{}
+}
+
+// recorder keeps the calls made to a mock.
+type recorder struct {
+	mu    sync.Mutex
+	calls []MockCall
+	seq   int
+}
+
+func (r *recorder) record(method string, args ...interface{}) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, MockCall{Method: method, Args: args})
+	r.seq++
+	return r.seq
+}
+
+// Calls returns the calls made so far, oldest first.
+func (r *recorder) Calls() []MockCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]MockCall(nil), r.calls...)
+}
+
+// CallsTo returns the calls made to method.
+func (r *recorder) CallsTo(method string) []MockCall {
+	var out []MockCall
+	for _, c := range r.Calls() {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// MockSender is a zeptomail.Sender that records every call. Each method
+// delegates to the matching Func field when set and otherwise succeeds.
+type MockSender struct {
+	recorder
+
+	SendEmailFunc              func(ctx context.Context, req *zeptomail.EmailRequest) (*zeptomail.SuccessResponse, error)
+	SendBatchEmailFunc         func(ctx context.Context, req *zeptomail.EmailRequest) (*zeptomail.SuccessResponse, error)
+	SendTemplateEmailFunc      func(ctx context.Context, req *zeptomail.TemplateRequest) (*zeptomail.SuccessResponse, error)
+	SendBatchTemplateEmailFunc func(ctx context.Context, req *zeptomail.TemplateRequest) (*zeptomail.SuccessResponse, error)
+	FileCacheUploadFunc        func(ctx context.Context, filename string, content []byte) (*zeptomail.FileUploadResponse, error)
+}
+
+var _ zeptomail.Sender = (*MockSender)(nil)
+
+func (m *MockSender) SendEmail(ctx context.Context, req *zeptomail.EmailRequest) (*zeptomail.SuccessResponse, error) {
+	n := m.record("SendEmail", req)
+	if m.SendEmailFunc != nil {
+		return m.SendEmailFunc(ctx, req)
+	}
+	return mockSuccess(n), nil
+}
+
+func (m *MockSender) SendBatchEmail(ctx context.Context, req *zeptomail.EmailRequest) (*zeptomail.SuccessResponse, error) {
+	n := m.record("SendBatchEmail", req)
+	if m.SendBatchEmailFunc != nil {
+		return m.SendBatchEmailFunc(ctx, req)
+	}
+	return mockSuccess(n), nil
+}
+
+func (m *MockSender) SendTemplateEmail(ctx context.Context, req *zeptomail.TemplateRequest) (*zeptomail.SuccessResponse, error) {
+	n := m.record("SendTemplateEmail", req)
+	if m.SendTemplateEmailFunc != nil {
+		return m.SendTemplateEmailFunc(ctx, req)
+	}
+	return mockSuccess(n), nil
+}
+
+func (m *MockSender) SendBatchTemplateEmail(ctx context.Context, req *zeptomail.TemplateRequest) (*zeptomail.SuccessResponse, error) {
+	n := m.record("SendBatchTemplateEmail", req)
+	if m.SendBatchTemplateEmailFunc != nil {
+		return m.SendBatchTemplateEmailFunc(ctx, req)
+	}
+	return mockSuccess(n), nil
+}
+
+func (m *MockSender) FileCacheUpload(ctx context.Context, filename string, content []byte) (*zeptomail.FileUploadResponse, error) {
+	n := m.record("FileCacheUpload", filename, content)
+	if m.FileCacheUploadFunc != nil {
+		return m.FileCacheUploadFunc(ctx, filename, content)
+	}
+	return &zeptomail.FileUploadResponse{FileCacheKey: fmt.Sprintf("mock-fck-%d", n), Message: "OK", Object: "files"}, nil
+}
+
+// MockTemplateManager is a zeptomail.TemplateManager that records every
+// call. Each method delegates to the matching Func field when set and
+// otherwise returns an empty successful response.
+type MockTemplateManager struct {
+	recorder
+
+	CreateTemplateFunc func(ctx context.Context, mailagentAlias string, req *zeptomail.CreateTemplateRequest) (*zeptomail.CreateTemplateResponse, error)
+	GetTemplateFunc    func(ctx context.Context, mailagentAlias, templateKey string) (*zeptomail.GetTemplateResponse, error)
+	UpdateTemplateFunc func(ctx context.Context, mailagentAlias, templateKey string, req *zeptomail.UpdateTemplateRequest) (*zeptomail.CreateTemplateResponse, error)
+	ListTemplatesFunc  func(ctx context.Context, mailagentAlias string, params zeptomail.ListTemplatesParams) (*zeptomail.ListTemplatesResponse, error)
+	DeleteTemplateFunc func(ctx context.Context, mailagentAlias, templateKey string) error
+}
+
+var _ zeptomail.TemplateManager = (*MockTemplateManager)(nil)
+
+func (m *MockTemplateManager) CreateTemplate(ctx context.Context, mailagentAlias string, req *zeptomail.CreateTemplateRequest) (*zeptomail.CreateTemplateResponse, error) {
+	n := m.record("CreateTemplate", mailagentAlias, req)
+	if m.CreateTemplateFunc != nil {
+		return m.CreateTemplateFunc(ctx, mailagentAlias, req)
+	}
+	return &zeptomail.CreateTemplateResponse{
+		Data: []zeptomail.TemplateData{{
+			TemplateKey:   fmt.Sprintf("mock-tpl-%d", n),
+			TemplateName:  req.TemplateName,
+			TemplateAlias: req.TemplateAlias,
+			Subject:       req.Subject,
+			HTMLBody:      req.HTMLBody,
+		}},
+		Message: "OK",
+		Object:  "template",
+	}, nil
+}
+
+func (m *MockTemplateManager) GetTemplate(ctx context.Context, mailagentAlias, templateKey string) (*zeptomail.GetTemplateResponse, error) {
+	m.record("GetTemplate", mailagentAlias, templateKey)
+	if m.GetTemplateFunc != nil {
+		return m.GetTemplateFunc(ctx, mailagentAlias, templateKey)
+	}
+	return &zeptomail.GetTemplateResponse{Data: zeptomail.TemplateData{TemplateKey: templateKey}, Message: "OK", Object: "template"}, nil
+}
+
+func (m *MockTemplateManager) UpdateTemplate(ctx context.Context, mailagentAlias, templateKey string, req *zeptomail.UpdateTemplateRequest) (*zeptomail.CreateTemplateResponse, error) {
+	m.record("UpdateTemplate", mailagentAlias, templateKey, req)
+	if m.UpdateTemplateFunc != nil {
+		return m.UpdateTemplateFunc(ctx, mailagentAlias, templateKey, req)
+	}
+	return &zeptomail.CreateTemplateResponse{
+		Data: []zeptomail.TemplateData{{
+			TemplateKey:   templateKey,
+			TemplateName:  req.TemplateName,
+			TemplateAlias: req.TemplateAlias,
+			Subject:       req.Subject,
+			HTMLBody:      req.HTMLBody,
+		}},
+		Message: "OK",
+		Object:  "template",
+	}, nil
+}
+
+func (m *MockTemplateManager) ListTemplates(ctx context.Context, mailagentAlias string, params zeptomail.ListTemplatesParams) (*zeptomail.ListTemplatesResponse, error) {
+	m.record("ListTemplates", mailagentAlias, params)
+	if m.ListTemplatesFunc != nil {
+		return m.ListTemplatesFunc(ctx, mailagentAlias, params)
+	}
+	return &zeptomail.ListTemplatesResponse{
+		Metadata: zeptomail.ListTemplatesMetadata{Offset: params.Offset, Limit: params.Limit},
+		Data:     []zeptomail.TemplateListItem{},
+		Message:  "OK",
+	}, nil
+}
+
+func (m *MockTemplateManager) DeleteTemplate(ctx context.Context, mailagentAlias, templateKey string) error {
+	m.record("DeleteTemplate", mailagentAlias, templateKey)
+	if m.DeleteTemplateFunc != nil {
+		return m.DeleteTemplateFunc(ctx, mailagentAlias, templateKey)
+	}
+	return nil
+}
+
+func mockSuccess(n int) *zeptomail.SuccessResponse {
+	return &zeptomail.SuccessResponse{
+		Data:      []zeptomail.ResponseData{{Code: "EM_104", Message: "Email request received"}},
+		Message:   "OK",
+		RequestID: fmt.Sprintf("mock-req-%d", n),
+		Object:    "email",
+	}
+}
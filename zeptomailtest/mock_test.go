@@ -0,0 +1,69 @@
+package zeptomailtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/navnitms/zeptomail-sdk-go"
+)
+
+// notify is an example of code under test that depends on the interface.
+func notify(ctx context.Context, s zeptomail.Sender, to string) error {
+	req := testEmail()
+	req.To[0].Address = to
+	_, err := s.SendEmail(ctx, req)
+	return err
+}
+
+func TestMockSender_RecordsCalls(t *testing.T) {
+	m := &MockSender{}
+	if err := notify(context.Background(), m, "user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.FileCacheUpload(context.Background(), "a.txt", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := m.Calls()
+	if len(calls) != 2 || calls[0].Method != "SendEmail" || calls[1].Method != "FileCacheUpload" {
+		t.Fatalf("calls = %+v", calls)
+	}
+	req := calls[0].Args[0].(*zeptomail.EmailRequest)
+	if req.To[0].Address != "user@example.com" {
+		t.Errorf("recorded recipient = %q", req.To[0].Address)
+	}
+	if got := m.CallsTo("FileCacheUpload")[0].Args[0]; got != "a.txt" {
+		t.Errorf("filename arg = %v", got)
+	}
+}
+
+func TestMockSender_Func(t *testing.T) {
+	want := &zeptomail.APIError{HTTPStatusCode: 429}
+	m := &MockSender{
+		SendEmailFunc: func(ctx context.Context, req *zeptomail.EmailRequest) (*zeptomail.SuccessResponse, error) {
+			return nil, want
+		},
+	}
+	if err := notify(context.Background(), m, "user@example.com"); !errors.Is(err, zeptomail.ErrRateLimited) {
+		t.Errorf("err = %v, want the scripted error", err)
+	}
+}
+
+func TestMockTemplateManager(t *testing.T) {
+	var tm zeptomail.TemplateManager = &MockTemplateManager{}
+	resp, err := tm.CreateTemplate(context.Background(), "agent", &zeptomail.CreateTemplateRequest{TemplateName: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data[0].TemplateName != "t" || resp.Data[0].TemplateKey == "" {
+		t.Errorf("resp = %+v", resp.Data[0])
+	}
+	if err := tm.DeleteTemplate(context.Background(), "agent", "k"); err != nil {
+		t.Fatal(err)
+	}
+	m := tm.(*MockTemplateManager)
+	if calls := m.CallsTo("DeleteTemplate"); len(calls) != 1 || calls[0].Args[1] != "k" {
+		t.Errorf("delete calls = %+v", calls)
+	}
+}
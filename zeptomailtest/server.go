@@ -0,0 +1,477 @@
+// Package zeptomailtest provides test doubles for the ZeptoMail SDK.
+//
+// Server is an in-memory fake of the ZeptoMail API. It implements the email,
+// batch, template send, file cache and template management endpoints, records
+// every message it accepts and can be scripted to fail.
+//
+//	srv := zeptomailtest.NewServer()
+//	defer srv.Close()
+//
+//	client := srv.EmailClient()
+//	_, err := client.SendEmail(ctx, req)
+//	msgs := srv.Messages()
+//
+// SMTPServer is a fake of the ZeptoMail SMTP relay for testing SMTPClient.
+//
+// MockSender and MockTemplateManager implement zeptomail.Sender and
+// zeptomail.TemplateManager without any HTTP, recording every call.
+package zeptomailtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go"
+)
+
+// Message is a send request accepted by the Server.
+type Message struct {
+	// Operation is the client method that maps to the route, e.g.
+	// "SendEmail" or "SendBatchTemplateEmail".
+	Operation string
+	RequestID string
+	Header    http.Header
+	// Email is set for SendEmail and SendBatchEmail.
+	Email *zeptomail.EmailRequest
+	// Template is set for SendTemplateEmail and SendBatchTemplateEmail.
+	Template *zeptomail.TemplateRequest
+}
+
+// Failure scripts an error response.
+type Failure struct {
+	// Path restricts the failure to requests whose path starts with it.
+	// Empty matches every request.
+	Path string
+	// Status is the HTTP status to return.
+	Status int
+	// Code and Message fill the JSON error body. Code defaults to the
+	// status text.
+	Code    string
+	Message string
+	// RetryAfter, when set, is sent as the Retry-After header.
+	RetryAfter string
+	// Times is how many matching requests fail. Zero means once.
+	Times int
+}
+
+// Server is a fake ZeptoMail API backed by an httptest.Server.
+type Server struct {
+	// URL is the base URL to pass to zeptomail.WithBaseURL.
+	URL string
+
+	// APIKey and OAuthToken, when set, are the only credentials the server
+	// accepts. Otherwise any credential with the right scheme is accepted.
+	APIKey     string
+	OAuthToken string
+
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	seq       int
+	messages  []Message
+	files     map[string][]byte
+	templates map[string][]zeptomail.TemplateData // by mail agent alias
+	failures  []*Failure
+}
+
+// NewServer starts a fake server. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		files:     make(map[string][]byte),
+		templates: make(map[string][]zeptomail.TemplateData),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// EmailClient returns a client pointed at the server. opts are applied after
+// WithBaseURL.
+func (s *Server) EmailClient(opts ...zeptomail.Option) *zeptomail.EmailClient {
+	key := s.APIKey
+	if key == "" {
+		key = "test-api-key"
+	}
+	return zeptomail.NewEmailClient(key, append([]zeptomail.Option{zeptomail.WithBaseURL(s.URL)}, opts...)...)
+}
+
+// TemplatesClient returns a templates client pointed at the server. opts are
+// applied after WithBaseURL.
+func (s *Server) TemplatesClient(opts ...zeptomail.Option) *zeptomail.TemplatesClient {
+	token := s.OAuthToken
+	if token == "" {
+		token = "test-oauth-token"
+	}
+	return zeptomail.NewTemplatesClient(token, append([]zeptomail.Option{zeptomail.WithBaseURL(s.URL)}, opts...)...)
+}
+
+// Messages returns the messages accepted so far, oldest first.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// File returns the content uploaded under a file cache key.
+func (s *Server) File(fileCacheKey string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.files[fileCacheKey]
+	return b, ok
+}
+
+// AddTemplate stores a template directly, bypassing the API. An empty
+// TemplateKey is generated.
+func (s *Server) AddTemplate(mailagentAlias string, tpl zeptomail.TemplateData) zeptomail.TemplateData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tpl.TemplateKey == "" {
+		tpl.TemplateKey = s.nextID("tpl")
+	}
+	s.templates[mailagentAlias] = append(s.templates[mailagentAlias], tpl)
+	return tpl
+}
+
+// Fail queues a scripted failure. Failures are matched in the order added.
+func (s *Server) Fail(f Failure) {
+	if f.Times <= 0 {
+		f.Times = 1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &f)
+}
+
+// FailNext makes the next request, whatever its route, fail with status and
+// ZeptoMail error code.
+func (s *Server) FailNext(status int, code string) {
+	s.Fail(Failure{Status: status, Code: code})
+}
+
+// Reset drops all recorded messages, files, templates and pending failures.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+	s.files = make(map[string][]byte)
+	s.templates = make(map[string][]zeptomail.TemplateData)
+	s.failures = nil
+}
+
+func (s *Server) nextID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s-%d", prefix, s.seq)
+}
+
+func (s *Server) takeFailure(path string) *Failure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.failures {
+		if strings.HasPrefix(path, f.Path) {
+			f.Times--
+			if f.Times == 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+			return f
+		}
+	}
+	return nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if f := s.takeFailure(r.URL.Path); f != nil {
+		if f.RetryAfter != "" {
+			w.Header().Set("Retry-After", f.RetryAfter)
+		}
+		code := f.Code
+		if code == "" {
+			code = http.StatusText(f.Status)
+		}
+		writeError(w, f.Status, code, f.Message)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/mailagents/") {
+		if !s.authorized(r, "Zoho-oauthtoken ", s.OAuthToken) {
+			writeError(w, http.StatusUnauthorized, "INVALID_OAUTHTOKEN", "Invalid OAuth token")
+			return
+		}
+		s.serveTemplates(w, r)
+		return
+	}
+
+	if !s.authorized(r, "Zoho-enczapikey ", s.APIKey) {
+		writeError(w, http.StatusUnauthorized, "SERR_157", "Invalid API Token found")
+		return
+	}
+	switch r.URL.Path {
+	case "/email":
+		s.serveSend(w, r, "SendEmail", false)
+	case "/email/batch":
+		s.serveSend(w, r, "SendBatchEmail", false)
+	case "/email/template":
+		s.serveSend(w, r, "SendTemplateEmail", true)
+	case "/email/template/batch":
+		s.serveSend(w, r, "SendBatchTemplateEmail", true)
+	case "/files":
+		s.serveUpload(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Unknown endpoint "+r.URL.Path)
+	}
+}
+
+func (s *Server) authorized(r *http.Request, scheme, want string) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, scheme) {
+		return false
+	}
+	got := strings.TrimPrefix(auth, scheme)
+	if want != "" {
+		return got == want
+	}
+	return got != ""
+}
+
+func (s *Server) serveSend(w http.ResponseWriter, r *http.Request, operation string, template bool) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "GE_102", "Method not allowed")
+		return
+	}
+
+	msg := Message{Operation: operation, Header: r.Header.Clone()}
+	var err error
+	if template {
+		msg.Template = &zeptomail.TemplateRequest{}
+		err = json.NewDecoder(r.Body).Decode(msg.Template)
+	} else {
+		msg.Email = &zeptomail.EmailRequest{}
+		err = json.NewDecoder(r.Body).Decode(msg.Email)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "TM_3301", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if template && !s.templateExists(msg.Template.TemplateKey, msg.Template.TemplateAlias) {
+		writeError(w, http.StatusNotFound, "TM_3501", "Template not found")
+		return
+	}
+	msg.RequestID = s.nextID("req")
+	s.messages = append(s.messages, msg)
+
+	writeJSON(w, http.StatusCreated, zeptomail.SuccessResponse{
+		Data:      []zeptomail.ResponseData{{Code: "EM_104", Message: "Email request received"}},
+		Message:   "OK",
+		RequestID: msg.RequestID,
+		Object:    "email",
+	})
+}
+
+// templateExists reports whether any mail agent has a template with the
+// given key or alias. The caller holds s.mu.
+func (s *Server) templateExists(key, alias string) bool {
+	for _, tpls := range s.templates {
+		for _, t := range tpls {
+			if (key != "" && t.TemplateKey == key) || (alias != "" && t.TemplateAlias == alias) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Server) serveUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "GE_102", "Method not allowed")
+		return
+	}
+	if r.URL.Query().Get("name") == "" {
+		writeError(w, http.StatusBadRequest, "TM_3201", "name is required")
+		return
+	}
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "TM_3301", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	key := s.nextID("fck")
+	s.files[key] = content
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, zeptomail.FileUploadResponse{
+		FileCacheKey: key,
+		Data:         []zeptomail.ResponseData{},
+		Message:      "OK",
+		Object:       "files",
+	})
+}
+
+func (s *Server) serveTemplates(w http.ResponseWriter, r *http.Request) {
+	// /mailagents/{alias}/templates[/{key}]
+	parts := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	if len(parts) < 3 || len(parts) > 4 || parts[2] != "templates" {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Unknown endpoint "+r.URL.Path)
+		return
+	}
+	alias, err := url.PathUnescape(parts[1])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "TM_3301", err.Error())
+		return
+	}
+	if len(parts) == 3 {
+		switch r.Method {
+		case http.MethodPost:
+			s.createTemplate(w, r, alias)
+		case http.MethodGet:
+			s.listTemplates(w, r, alias)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "GE_102", "Method not allowed")
+		}
+		return
+	}
+
+	key, err := url.PathUnescape(parts[3])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "TM_3301", err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := -1
+	for i, t := range s.templates[alias] {
+		if t.TemplateKey == key {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		writeError(w, http.StatusNotFound, "TM_3501", "Template not found")
+		return
+	}
+
+	tpls := s.templates[alias]
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, zeptomail.GetTemplateResponse{Data: tpls[idx], Message: "OK", Object: "template"})
+	case http.MethodPut:
+		var req zeptomail.CreateTemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "TM_3301", "Invalid JSON: "+err.Error())
+			return
+		}
+		// Only the fields present in the request are changed.
+		t := &tpls[idx]
+		for _, f := range []struct {
+			dst *string
+			src string
+		}{
+			{&t.TemplateName, req.TemplateName},
+			{&t.TemplateAlias, req.TemplateAlias},
+			{&t.Subject, req.Subject},
+			{&t.HTMLBody, req.HTMLBody},
+		} {
+			if f.src != "" {
+				*f.dst = f.src
+			}
+		}
+		t.ModifiedTime = now()
+		writeJSON(w, http.StatusOK, zeptomail.CreateTemplateResponse{Data: []zeptomail.TemplateData{*t}, Message: "OK", Object: "template"})
+	case http.MethodDelete:
+		s.templates[alias] = append(tpls[:idx], tpls[idx+1:]...)
+		writeJSON(w, http.StatusOK, map[string]string{"message": "OK"})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "GE_102", "Method not allowed")
+	}
+}
+
+func (s *Server) createTemplate(w http.ResponseWriter, r *http.Request, alias string) {
+	var req zeptomail.CreateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "TM_3301", "Invalid JSON: "+err.Error())
+		return
+	}
+	if req.TemplateName == "" || req.Subject == "" {
+		writeError(w, http.StatusBadRequest, "TM_3201", "template_name and subject are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ts := now()
+	tpl := zeptomail.TemplateData{
+		TemplateKey:   s.nextID("tpl"),
+		TemplateName:  req.TemplateName,
+		TemplateAlias: req.TemplateAlias,
+		Subject:       req.Subject,
+		HTMLBody:      req.HTMLBody,
+		CreatedTime:   ts,
+		ModifiedTime:  ts,
+	}
+	s.templates[alias] = append(s.templates[alias], tpl)
+	writeJSON(w, http.StatusCreated, zeptomail.CreateTemplateResponse{Data: []zeptomail.TemplateData{tpl}, Message: "OK", Object: "template"})
+}
+
+func (s *Server) listTemplates(w http.ResponseWriter, r *http.Request, alias string) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tpls := s.templates[alias]
+	resp := zeptomail.ListTemplatesResponse{
+		Metadata: zeptomail.ListTemplatesMetadata{Offset: offset, Limit: limit, Count: len(tpls)},
+		Data:     []zeptomail.TemplateListItem{},
+		Message:  "OK",
+	}
+	for i := offset; i < len(tpls) && (limit <= 0 || i < offset+limit); i++ {
+		t := tpls[i]
+		resp.Data = append(resp.Data, zeptomail.TemplateListItem{
+			CreatedTime:   t.CreatedTime,
+			TemplateName:  t.TemplateName,
+			TemplateKey:   t.TemplateKey,
+			ModifiedTime:  t.ModifiedTime,
+			Subject:       t.Subject,
+			TemplateAlias: t.TemplateAlias,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	var resp zeptomail.ErrorResponse
+	resp.Error.Code = code
+	resp.Error.Message = message
+	resp.Error.Details = []zeptomail.ErrorDetail{}
+	resp.Error.RequestID = fmt.Sprintf("err-%d", time.Now().UnixNano())
+	writeJSON(w, status, resp)
+}
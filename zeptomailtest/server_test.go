@@ -0,0 +1,179 @@
+package zeptomailtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/navnitms/zeptomail-sdk-go"
+)
+
+func testEmail() *zeptomail.EmailRequest {
+	return &zeptomail.EmailRequest{
+		From:     zeptomail.EmailAddress{Address: "sender@example.com"},
+		To:       []zeptomail.Recipient{{EmailAddress: zeptomail.EmailAddress{Address: "to@example.com"}}},
+		Subject:  "Hello",
+		TextBody: "Hi there",
+	}
+}
+
+func TestServer_RecordsMessages(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	client := srv.EmailClient()
+	resp, err := client.SendEmail(context.Background(), testEmail())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SendBatchEmail(context.Background(), testEmail()); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := srv.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("messages = %d, want 2", len(msgs))
+	}
+	if msgs[0].Operation != "SendEmail" || msgs[0].RequestID != resp.RequestID {
+		t.Errorf("first message = %+v, want SendEmail with request %q", msgs[0], resp.RequestID)
+	}
+	if msgs[0].Email.Subject != "Hello" || msgs[0].Email.To[0].Address != "to@example.com" {
+		t.Errorf("recorded email = %+v", msgs[0].Email)
+	}
+	if msgs[1].Operation != "SendBatchEmail" {
+		t.Errorf("second operation = %q", msgs[1].Operation)
+	}
+}
+
+func TestServer_TemplateLifecycle(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	tc := srv.TemplatesClient()
+
+	created, err := tc.CreateTemplate(ctx, "agent", &zeptomail.CreateTemplateRequest{
+		TemplateName:  "Welcome",
+		TemplateAlias: "welcome",
+		Subject:       "Welcome {{name}}",
+		HTMLBody:      "<p>Hi {{name}}</p>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := created.Data[0].TemplateKey
+
+	got, err := tc.GetTemplate(ctx, "agent", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Data.TemplateName != "Welcome" {
+		t.Errorf("TemplateName = %q", got.Data.TemplateName)
+	}
+
+	if _, err := tc.UpdateTemplate(ctx, "agent", key, &zeptomail.UpdateTemplateRequest{TemplateName: "Welcome v2", Subject: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := tc.ListTemplates(ctx, "agent", zeptomail.ListTemplatesParams{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 || list.Data[0].TemplateName != "Welcome v2" {
+		t.Errorf("list = %+v", list.Data)
+	}
+
+	_, err = srv.EmailClient().SendTemplateEmail(ctx, &zeptomail.TemplateRequest{
+		TemplateAlias: "welcome",
+		From:          zeptomail.EmailAddress{Address: "sender@example.com"},
+		To:            []zeptomail.Recipient{{EmailAddress: zeptomail.EmailAddress{Address: "to@example.com"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tc.DeleteTemplate(ctx, "agent", key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.GetTemplate(ctx, "agent", key); !errors.Is(err, zeptomail.ErrTemplateNotFound) {
+		t.Errorf("err = %v, want template not found", err)
+	}
+}
+
+func TestServer_UnknownTemplateSend(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	_, err := srv.EmailClient().SendTemplateEmail(context.Background(), &zeptomail.TemplateRequest{
+		TemplateKey: "missing",
+		From:        zeptomail.EmailAddress{Address: "sender@example.com"},
+		To:          []zeptomail.Recipient{{EmailAddress: zeptomail.EmailAddress{Address: "to@example.com"}}},
+	})
+	if !errors.Is(err, zeptomail.ErrTemplateNotFound) {
+		t.Errorf("err = %v, want template not found", err)
+	}
+}
+
+func TestServer_FileUpload(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	resp, err := srv.EmailClient().FileCacheUpload(context.Background(), "a.txt", []byte("content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := srv.File(resp.FileCacheKey)
+	if !ok || string(got) != "content" {
+		t.Errorf("File(%q) = %q, %v", resp.FileCacheKey, got, ok)
+	}
+}
+
+func TestServer_ScriptedFailures(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.Fail(Failure{Path: "/email", Status: 429, Code: "TM_8001", Times: 2})
+	client := srv.EmailClient()
+	for i := 0; i < 2; i++ {
+		if _, err := client.SendEmail(context.Background(), testEmail()); !errors.Is(err, zeptomail.ErrRateLimited) {
+			t.Fatalf("attempt %d: err = %v, want rate limited", i+1, err)
+		}
+	}
+	if _, err := client.SendEmail(context.Background(), testEmail()); err != nil {
+		t.Fatalf("third attempt: %v", err)
+	}
+
+	srv.FailNext(500, "")
+	var apiErr *zeptomail.APIError
+	_, err := client.SendEmail(context.Background(), testEmail())
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != 500 || apiErr.Code != "Internal Server Error" {
+		t.Errorf("err = %v, want 500", err)
+	}
+	if len(srv.Messages()) != 1 {
+		t.Errorf("messages = %d, failed requests must not be recorded", len(srv.Messages()))
+	}
+}
+
+func TestServer_RetriesAgainstFailures(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.Fail(Failure{Status: 503, RetryAfter: "0"})
+	client := srv.EmailClient(zeptomail.WithRetryPolicy(zeptomail.RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond}))
+	if _, err := client.SendEmail(context.Background(), testEmail()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_Auth(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.APIKey = "right"
+
+	bad := zeptomail.NewEmailClient("wrong", zeptomail.WithBaseURL(srv.URL))
+	if _, err := bad.SendEmail(context.Background(), testEmail()); !errors.Is(err, zeptomail.ErrUnauthorized) {
+		t.Errorf("err = %v, want unauthorized", err)
+	}
+	if _, err := srv.EmailClient().SendEmail(context.Background(), testEmail()); err != nil {
+		t.Errorf("helper client should use the configured key: %v", err)
+	}
+}
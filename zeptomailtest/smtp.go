@@ -0,0 +1,222 @@
+package zeptomailtest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+
+	"github.com/navnitms/zeptomail-sdk-go"
+)
+
+// SMTPMessage is a message accepted by the SMTPServer.
+type SMTPMessage struct {
+	// From and To are the envelope sender and recipients.
+	From string
+	To   []string
+	// Data is the raw message as received, with dot-stuffing removed.
+	Data []byte
+}
+
+// SMTPServer is a fake ZeptoMail SMTP relay listening on 127.0.0.1. It
+// speaks enough SMTP for net/smtp: EHLO, AUTH PLAIN, MAIL, RCPT, DATA, RSET,
+// NOOP and QUIT, without TLS.
+type SMTPServer struct {
+	// Addr is the host:port to pass to zeptomail.WithSMTPAddr.
+	Addr string
+
+	// APIKey, when set, is the only password accepted for AUTH. Otherwise
+	// any password is accepted. Mail is refused until the client has
+	// authenticated.
+	APIKey string
+
+	ln net.Listener
+	wg sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []SMTPMessage
+	failures []smtpFailure
+}
+
+type smtpFailure struct {
+	code int
+	msg  string
+}
+
+// NewSMTPServer starts a fake relay. Call Close when done.
+func NewSMTPServer() *SMTPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("zeptomailtest: failed to listen: %v", err))
+	}
+	s := &SMTPServer{Addr: ln.Addr().String(), ln: ln}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Close stops the server and waits for open connections to finish.
+func (s *SMTPServer) Close() {
+	s.ln.Close()
+	s.wg.Wait()
+}
+
+// Client returns an SMTPClient pointed at the server, with WithInsecureSMTP
+// since the server has no TLS. opts are applied after those.
+func (s *SMTPServer) Client(opts ...zeptomail.SMTPOption) *zeptomail.SMTPClient {
+	key := s.APIKey
+	if key == "" {
+		key = "test-api-key"
+	}
+	return zeptomail.NewSMTPClient(key, append([]zeptomail.SMTPOption{zeptomail.WithSMTPAddr(s.Addr), zeptomail.WithInsecureSMTP()}, opts...)...)
+}
+
+// Messages returns the messages accepted so far, oldest first.
+func (s *SMTPServer) Messages() []SMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SMTPMessage(nil), s.messages...)
+}
+
+// FailNext makes the server answer the end of the next DATA with the given
+// reply instead of accepting the message, e.g. 451 or 550.
+func (s *SMTPServer) FailNext(code int, msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, smtpFailure{code, msg})
+}
+
+func (s *SMTPServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.session(textproto.NewConn(conn))
+		}()
+	}
+}
+
+func (s *SMTPServer) session(c *textproto.Conn) {
+	var (
+		authed bool
+		msg    *SMTPMessage
+	)
+	c.PrintfLine("220 zeptomailtest ESMTP ready")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			c.PrintfLine("250-zeptomailtest")
+			c.PrintfLine("250-AUTH PLAIN")
+			c.PrintfLine("250 8BITMIME")
+		case "AUTH":
+			mech, initial, _ := strings.Cut(arg, " ")
+			if !strings.EqualFold(mech, "PLAIN") {
+				c.PrintfLine("504 5.5.4 unrecognized authentication type")
+				continue
+			}
+			if initial == "" {
+				c.PrintfLine("334 ")
+				if initial, err = c.ReadLine(); err != nil {
+					return
+				}
+			}
+			if s.checkAuth(initial) {
+				authed = true
+				c.PrintfLine("235 2.7.0 authentication successful")
+			} else {
+				c.PrintfLine("535 5.7.8 authentication credentials invalid")
+			}
+		case "MAIL":
+			if !authed {
+				c.PrintfLine("530 5.7.0 authentication required")
+				continue
+			}
+			msg = &SMTPMessage{From: pathArg(arg, "FROM:")}
+			c.PrintfLine("250 2.1.0 ok")
+		case "RCPT":
+			if msg == nil {
+				c.PrintfLine("503 5.5.1 need MAIL first")
+				continue
+			}
+			msg.To = append(msg.To, pathArg(arg, "TO:"))
+			c.PrintfLine("250 2.1.5 ok")
+		case "DATA":
+			if msg == nil || len(msg.To) == 0 {
+				c.PrintfLine("503 5.5.1 need RCPT first")
+				continue
+			}
+			c.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(c.DotReader())
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			if f, ok := s.nextFailure(); ok {
+				c.PrintfLine("%d %s", f.code, f.msg)
+			} else {
+				s.mu.Lock()
+				s.messages = append(s.messages, *msg)
+				s.mu.Unlock()
+				c.PrintfLine("250 2.0.0 ok queued")
+			}
+			msg = nil
+		case "RSET":
+			msg = nil
+			c.PrintfLine("250 2.0.0 ok")
+		case "NOOP":
+			c.PrintfLine("250 2.0.0 ok")
+		case "QUIT":
+			c.PrintfLine("221 2.0.0 bye")
+			return
+		default:
+			c.PrintfLine("502 5.5.2 command not recognized")
+		}
+	}
+}
+
+// checkAuth verifies an AUTH PLAIN response: authzid NUL user NUL password.
+func (s *SMTPServer) checkAuth(initial string) bool {
+	b, err := base64.StdEncoding.DecodeString(initial)
+	if err != nil {
+		return false
+	}
+	parts := strings.Split(string(b), "\x00")
+	if len(parts) != 3 || parts[1] != "emailapikey" {
+		return false
+	}
+	return s.APIKey == "" || parts[2] == s.APIKey
+}
+
+func (s *SMTPServer) nextFailure() (smtpFailure, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) == 0 {
+		return smtpFailure{}, false
+	}
+	f := s.failures[0]
+	s.failures = s.failures[1:]
+	return f, true
+}
+
+// pathArg extracts the address from "FROM:<addr> ..." or "TO:<addr>".
+func pathArg(arg, prefix string) string {
+	if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+		arg = arg[len(prefix):]
+	}
+	arg, _, _ = strings.Cut(strings.TrimSpace(arg), " ")
+	return strings.Trim(arg, "<>")
+}